@@ -0,0 +1,223 @@
+package debug
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveOptions configures an Adaptive controller.
+type AdaptiveOptions struct {
+	// Pattern enabled on top of the current one while in trouble, such as
+	// "db:*,http:*".
+	Pattern string
+
+	// Watch is the pattern of the namespaces whose error records count as
+	// errors when the controller is added as a sink, all of them when
+	// empty.
+	Watch string
+
+	// Threshold is the number of errors within Window enabling Pattern,
+	// 10 when zero.
+	Threshold int
+
+	// Window of the errors, one minute when zero.
+	Window time.Duration
+
+	// Quiet is the period without errors after which Pattern is disabled
+	// again, five minutes when zero.
+	Quiet time.Duration
+}
+
+// Adaptive enables a set of namespaces automatically when errors exceed a
+// threshold, and disables it after a quiet period, so that production
+// services debug themselves when in trouble:
+//
+//	auto := debug.NewAdaptive(debug.AdaptiveOptions{
+//		Pattern:   "db:*,http:*",
+//		Threshold: 5,
+//		Window:    time.Minute,
+//	})
+//	defer auto.Close()
+//	debug.AddSink(auto)
+//
+// Errors are counted with Error, such as from the error path of a
+// handler, and, when added with AddSink, from the records at LevelError
+// of the namespaces matching Watch. Like sinks, it only receives the
+// records of enabled namespaces. Switches are written as warnings of the
+// "debug:adaptive" namespace. A change of the pattern while in trouble is
+// kept rather than undone when the quiet period ends.
+type Adaptive struct {
+	opts  AdaptiveOptions
+	watch *pattern
+
+	m      sync.Mutex
+	errors []time.Time
+	active atomic.Bool
+
+	trigger chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewAdaptive returns an Adaptive controller with `opts`. Close it to stop
+// it, disabling the pattern if in trouble.
+func NewAdaptive(opts AdaptiveOptions) *Adaptive {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 10
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+
+	if opts.Quiet <= 0 {
+		opts.Quiet = 5 * time.Minute
+	}
+
+	a := &Adaptive{
+		opts:    opts,
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if opts.Watch != "" {
+		m.Lock()
+		a.watch = compile(opts.Watch, false)
+		m.Unlock()
+	}
+
+	go a.run()
+	return a
+}
+
+// Error counts an error.
+//
+// This function is thread-safe.
+func (a *Adaptive) Error() {
+	a.count(time.Now())
+}
+
+// Active reports whether the pattern is enabled because of errors.
+func (a *Adaptive) Active() bool {
+	return a.active.Load()
+}
+
+// Write implements Sink, counting the records at LevelError of the watched
+// namespaces.
+func (a *Adaptive) Write(r *Record) error {
+	if r.Level >= LevelError && (a.watch == nil || a.watch.match(r.Name)) {
+		a.count(r.Time)
+	}
+	return nil
+}
+
+// Close stops the controller, disabling the pattern if in trouble.
+func (a *Adaptive) Close() error {
+	select {
+	case <-a.stop:
+	default:
+		close(a.stop)
+	}
+	<-a.done
+	return nil
+}
+
+// Count an error at `now`, triggering the controller once the threshold
+// is reached, or extending the trouble while in it.
+func (a *Adaptive) count(now time.Time) {
+	a.m.Lock()
+	a.errors = append(a.errors, now)
+	if len(a.errors) > a.opts.Threshold {
+		a.errors = append(a.errors[:0], a.errors[1:]...)
+	}
+	full := len(a.errors) == a.opts.Threshold && now.Sub(a.errors[0]) <= a.opts.Window
+	a.m.Unlock()
+
+	if full || a.active.Load() {
+		select {
+		case a.trigger <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Switch the pattern on triggers and quiet periods until stopped. Sinks
+// are written with the lock held, so the pattern is changed from here.
+func (a *Adaptive) run() {
+	defer close(a.done)
+
+	quiet := time.NewTimer(a.opts.Quiet)
+	quiet.Stop()
+
+	var restore func(reason string)
+	for {
+		select {
+		case <-a.trigger:
+			if restore == nil {
+				restore = a.enable()
+			}
+			quiet.Reset(a.opts.Quiet)
+		case <-quiet.C:
+			if restore != nil {
+				restore("no errors for " + a.opts.Quiet.String())
+				restore = nil
+			}
+		case <-a.stop:
+			quiet.Stop()
+			if restore != nil {
+				restore("closed")
+			}
+			return
+		}
+	}
+}
+
+// Enable the pattern on top of the current one, returning a function
+// restoring the previous one for `reason` unless it was changed meanwhile.
+func (a *Adaptive) enable() (restore func(reason string)) {
+	log := newNamespace("debug" + Separator() + "adaptive")
+
+	m.Lock()
+	prevPat, prevCurrent, prevOrdered := active.Load(), current, ordered
+
+	p := a.opts.Pattern
+	if prevPat != nil && current != "" {
+		p = current + "," + p
+	}
+
+	next := compile(p, ordered)
+	if prevPat != nil {
+		next.custom = prevPat.custom
+	}
+
+	setActive(next)
+	current = p
+	generation++
+	gen := generation
+	a.active.Store(true)
+	unlock()
+
+	log.emit(LevelWarn, strconv.Itoa(a.opts.Threshold)+" errors within "+a.opts.Window.String()+", enabling "+a.opts.Pattern, nil)
+
+	return func(reason string) {
+		m.Lock()
+		restored := generation == gen
+		if restored {
+			setActive(prevPat)
+			current, ordered = prevCurrent, prevOrdered
+			generation++
+		}
+		a.m.Lock()
+		a.errors = a.errors[:0]
+		a.m.Unlock()
+		a.active.Store(false)
+		unlock()
+
+		if restored {
+			log.emit(LevelWarn, reason+", disabling "+a.opts.Pattern, nil)
+		}
+	}
+}
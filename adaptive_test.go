@@ -0,0 +1,97 @@
+package debug
+
+import "bytes"
+import "testing"
+import "time"
+
+// Wait for `cond` to hold, as the controller switches from a goroutine.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met")
+}
+
+func TestAdaptive(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "api")()
+
+	a := NewAdaptive(AdaptiveOptions{
+		Pattern:   "db:*",
+		Watch:     "api",
+		Threshold: 3,
+		Window:    time.Minute,
+		Quiet:     50 * time.Millisecond,
+	})
+	defer a.Close()
+	defer AddSink(a)()
+
+	db := Named("db:query")
+	api := Named("api")
+
+	api.Errorf("failed")
+	a.Error()
+	if a.Active() || db.Enabled() {
+		t.Fatal("expected no trouble below the threshold")
+	}
+
+	api.Printf("not an error")
+	api.Errorf("failed")
+	eventually(t, func() bool { return db.Enabled() })
+
+	if !a.Active() || !api.Enabled() {
+		t.Fatal("expected the pattern to be added to the current one")
+	}
+
+	eventually(t, func() bool { return !db.Enabled() && !a.Active() })
+
+	if Config().Pattern != "api" {
+		t.Fatalf("expected the previous pattern to be restored, got %q", Config().Pattern)
+	}
+
+	m.Lock()
+	out := buf.String()
+	m.Unlock()
+	assertContains(t, out, "3 errors within 1m0s, enabling db:*")
+	assertContains(t, out, "no errors for 50ms, disabling db:*")
+}
+
+func TestAdaptiveWindow(t *testing.T) {
+	a := NewAdaptive(AdaptiveOptions{Pattern: "db:*", Threshold: 2, Window: time.Second})
+	defer a.Close()
+
+	now := time.Now()
+	a.Write(&Record{Time: now.Add(-2 * time.Second), Name: "x", Level: LevelError})
+	a.Write(&Record{Time: now, Name: "x", Level: LevelError})
+
+	time.Sleep(20 * time.Millisecond)
+	if a.Active() {
+		t.Fatal("expected errors spread over more than the window not to trigger")
+	}
+}
+
+func TestAdaptiveChanged(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "api")()
+
+	a := NewAdaptive(AdaptiveOptions{Pattern: "db:*", Threshold: 1, Quiet: time.Hour})
+	a.Error()
+	eventually(t, a.Active)
+
+	Enable("other")
+	a.Close()
+
+	if Config().Pattern != "other" {
+		t.Fatalf("expected a pattern changed meanwhile to be kept, got %q", Config().Pattern)
+	}
+
+	m.Lock()
+	out := buf.String()
+	m.Unlock()
+	assertContains(t, out, "enabling db:*")
+	assertNotContains(t, out, "disabling")
+}
@@ -0,0 +1,262 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertOptions configures an AlertSink.
+type AlertOptions struct {
+	// URL of the webhook receiving the digests.
+	URL string
+
+	// Pattern of the namespaces alerted on, all of them when empty.
+	Pattern string
+
+	// Level is the lowest level alerted on. LevelDebug, the zero value,
+	// alerts on every record.
+	Level Level
+
+	// Interval between digests, one minute when zero.
+	Interval time.Duration
+
+	// Max distinct messages of a digest, 100 when zero. Others are only
+	// counted.
+	Max int
+
+	// Slack posts digests as Slack messages rather than as a Digest.
+	Slack bool
+
+	// Client posting the digests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// Digest is the JSON body posted by an AlertSink.
+type Digest struct {
+	Process string  `json:"process"`
+	Pid     int     `json:"pid"`
+	Host    string  `json:"host,omitempty"`
+	Alerts  []Alert `json:"alerts"`
+	Omitted int     `json:"omitted,omitempty"`
+}
+
+// Alert is a message of a Digest, with the number of records which had it.
+type Alert struct {
+	Name    string    `json:"name"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Count   int       `json:"count"`
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+}
+
+// AlertSink is a Sink notifying humans of rare but important records: it
+// collects those matching its namespace pattern and level, and posts a
+// digest of them to a webhook at most once per interval, with repeated
+// messages counted rather than repeated:
+//
+//	alerts := debug.NewAlertSink(debug.AlertOptions{
+//		URL:     os.Getenv("SLACK_WEBHOOK"),
+//		Pattern: "payments:*",
+//		Level:   debug.LevelError,
+//		Slack:   true,
+//	})
+//	defer alerts.Close()
+//	debug.AddSink(alerts)
+//
+// Records are only sent for enabled namespaces, like to other sinks.
+type AlertSink struct {
+	opts AlertOptions
+	pat  *pattern
+
+	m       sync.Mutex
+	alerts  []*Alert
+	index   map[string]*Alert
+	omitted int
+	err     error
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewAlertSink returns an AlertSink posting to the webhook of `opts`. Close
+// it to post the last digest.
+func NewAlertSink(opts AlertOptions) *AlertSink {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+
+	if opts.Max <= 0 {
+		opts.Max = 100
+	}
+
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	a := &AlertSink{
+		opts:  opts,
+		index: map[string]*Alert{},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if opts.Pattern != "" {
+		m.Lock()
+		a.pat = compile(opts.Pattern, false)
+		m.Unlock()
+	}
+
+	go a.run()
+	return a
+}
+
+// Write implements Sink, collecting `r` for the next digest.
+func (a *AlertSink) Write(r *Record) error {
+	if r.Level < a.opts.Level || (a.pat != nil && !a.pat.match(r.Name)) {
+		return nil
+	}
+
+	key := r.Name + "\x00" + r.Message
+	if r.Fingerprint != 0 {
+		key = r.Name + "\x00" + strconv.FormatUint(r.Fingerprint, 16)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if e, ok := a.index[key]; ok {
+		e.Count++
+		e.Last = r.Time
+		return nil
+	}
+
+	if len(a.alerts) == a.opts.Max {
+		a.omitted++
+		return nil
+	}
+
+	e := &Alert{
+		Name:    r.Name,
+		Level:   r.Level.String(),
+		Message: strings.Clone(r.Message),
+		Count:   1,
+		First:   r.Time,
+		Last:    r.Time,
+	}
+	a.alerts = append(a.alerts, e)
+	a.index[key] = e
+	return nil
+}
+
+// Close posts the pending digest and stops the sink, returning the error
+// of the last failed post, if any.
+func (a *AlertSink) Close() error {
+	select {
+	case <-a.stop:
+	default:
+		close(a.stop)
+	}
+	<-a.done
+
+	a.m.Lock()
+	defer a.m.Unlock()
+	return a.err
+}
+
+// Post a digest every interval until stopped.
+func (a *AlertSink) run() {
+	defer close(a.done)
+
+	t := time.NewTicker(a.opts.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			a.post()
+		case <-a.stop:
+			a.post()
+			return
+		}
+	}
+}
+
+// Post the collected alerts, if any.
+func (a *AlertSink) post() {
+	a.m.Lock()
+	d := Digest{Alerts: make([]Alert, len(a.alerts)), Omitted: a.omitted}
+	for i, e := range a.alerts {
+		d.Alerts[i] = *e
+	}
+	a.alerts, a.omitted = nil, 0
+	clear(a.index)
+	a.m.Unlock()
+
+	if len(d.Alerts) == 0 {
+		return
+	}
+
+	hello := newHello("")
+	d.Process, d.Pid, d.Host = hello.Process, hello.Pid, hello.Host
+
+	var body []byte
+	var err error
+	if a.opts.Slack {
+		body, err = json.Marshal(map[string]string{"text": slackText(d)})
+	} else {
+		body, err = json.Marshal(d)
+	}
+
+	if err == nil {
+		err = a.send(body)
+	}
+
+	if err != nil {
+		a.m.Lock()
+		a.err = err
+		a.m.Unlock()
+	}
+}
+
+// Post `body` to the webhook.
+func (a *AlertSink) send(body []byte) error {
+	res, err := a.opts.Client.Post(a.opts.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("debug: webhook responded %s", res.Status)
+	}
+	return nil
+}
+
+// Return digest `d` as the text of a Slack message.
+func slackText(d Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s[%d]", d.Process, d.Pid)
+	if d.Host != "" {
+		fmt.Fprintf(&b, " on %s", d.Host)
+	}
+	b.WriteString("*")
+
+	for _, e := range d.Alerts {
+		fmt.Fprintf(&b, "\n• `%s` %s: %s", e.Level, e.Name, e.Message)
+		if e.Count > 1 {
+			fmt.Fprintf(&b, " (×%d)", e.Count)
+		}
+	}
+
+	if d.Omitted > 0 {
+		fmt.Fprintf(&b, "\n…and %d more", d.Omitted)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package debug
+
+import "bytes"
+import "encoding/json"
+import "net/http"
+import "net/http/httptest"
+import "strings"
+import "sync"
+import "testing"
+import "time"
+
+func TestAlertSink(t *testing.T) {
+	var mu sync.Mutex
+	var digests []Digest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Digest
+		json.NewDecoder(r.Body).Decode(&d)
+		mu.Lock()
+		digests = append(digests, d)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	a := NewAlertSink(AlertOptions{
+		URL:      srv.URL,
+		Pattern:  "payments:*",
+		Level:    LevelWarn,
+		Interval: time.Hour,
+		Max:      2,
+	})
+	defer AddSink(a)()
+
+	log := Named("payments:charge")
+	for i := 0; i < 3; i++ {
+		log.Errorf("declined card %d", i)
+	}
+	log.Warnf("slow gateway")
+	log.Printf("charged")
+	log.Errorf("timeout")
+	Named("http").Errorf("not alerted")
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(digests) != 1 {
+		t.Fatalf("expected one digest, got %d", len(digests))
+	}
+
+	d := digests[0]
+	if len(d.Alerts) != 2 || d.Omitted != 1 || d.Pid == 0 {
+		t.Fatalf("unexpected digest %+v", d)
+	}
+
+	if e := d.Alerts[0]; e.Name != "payments:charge" || e.Level != "error" || e.Message != "declined card 0" || e.Count != 3 || e.Last.Before(e.First) {
+		t.Fatalf("unexpected alert %+v", e)
+	}
+
+	if e := d.Alerts[1]; e.Message != "slow gateway" || e.Count != 1 {
+		t.Fatalf("unexpected alert %+v", e)
+	}
+}
+
+func TestAlertSinkSlack(t *testing.T) {
+	body := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		body <- v
+	}))
+	defer srv.Close()
+
+	a := NewAlertSink(AlertOptions{URL: srv.URL, Interval: 10 * time.Millisecond, Slack: true})
+	defer a.Close()
+
+	now := time.Now()
+	a.Write(&Record{Time: now, Name: "db", Level: LevelError, Message: "replica down"})
+	a.Write(&Record{Time: now, Name: "db", Level: LevelError, Message: "replica down"})
+
+	select {
+	case v := <-body:
+		if !strings.Contains(v["text"], "\n• `error` db: replica down (×2)") {
+			t.Fatalf("unexpected text %q", v["text"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a digest to be posted")
+	}
+}
+
+func TestAlertSinkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	a := NewAlertSink(AlertOptions{URL: srv.URL})
+	a.Write(&Record{Time: time.Now(), Name: "db", Message: "hello"})
+
+	if err := a.Close(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected the webhook error, got %v", err)
+	}
+}
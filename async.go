@@ -0,0 +1,200 @@
+package debug
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy decides what an AsyncSink does when its queue is full.
+type Policy int
+
+// Policies.
+const (
+	// PolicyBlock waits for room in the queue, blocking the writing
+	// goroutine, and every other one writing debug output meanwhile.
+	PolicyBlock Policy = iota
+
+	// PolicyDropOldest discards the oldest queued record.
+	PolicyDropOldest
+
+	// PolicyDropNewest discards the record being written.
+	PolicyDropNewest
+
+	// PolicySummarize discards the record being written like
+	// PolicyDropNewest, and writes a warning with the number of records
+	// dropped once there is room again.
+	PolicySummarize
+)
+
+// String returns the name of the policy.
+func (p Policy) String() string {
+	switch p {
+	case PolicyBlock:
+		return "block"
+	case PolicyDropOldest:
+		return "drop-oldest"
+	case PolicyDropNewest:
+		return "drop-newest"
+	case PolicySummarize:
+		return "summarize"
+	default:
+		return "Policy(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// AsyncOptions configures an AsyncSink.
+type AsyncOptions struct {
+	// Size of the queue, 1024 records when zero.
+	Size int
+
+	// Policy when the queue is full, PolicyBlock by default.
+	Policy Policy
+}
+
+// AsyncSink is a Sink queueing records for another sink, which writes them
+// from a separate goroutine so that slow sinks, such as network ones, do
+// not slow down the program. Dropped records are counted in the Dropped
+// field of the Stats of their namespace. Unlike other sinks, the wrapped
+// sink is written without the package lock, so it must be safe for
+// concurrent use with the configuration functions.
+type AsyncSink struct {
+	sink   Sink
+	policy Policy
+
+	m       sync.Mutex
+	cond    *sync.Cond
+	queue   []*Record
+	head    int
+	count   int
+	dropped uint64
+	pending uint64
+	closed  bool
+	done    chan struct{}
+}
+
+// Async returns an AsyncSink writing to `s`. Add it with AddSink instead
+// of `s`, and Close it to write the queued records.
+func Async(s Sink, opts AsyncOptions) *AsyncSink {
+	if opts.Size <= 0 {
+		opts.Size = 1024
+	}
+
+	a := &AsyncSink{
+		sink:   s,
+		policy: opts.Policy,
+		queue:  make([]*Record, opts.Size),
+		done:   make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.m)
+
+	go a.run()
+	return a
+}
+
+// Write implements Sink, queueing a copy of `r`.
+func (a *AsyncSink) Write(r *Record) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for a.policy == PolicyBlock && a.count == len(a.queue) && !a.closed {
+		a.cond.Wait()
+	}
+
+	if a.closed {
+		return os.ErrClosed
+	}
+
+	if a.count == len(a.queue) {
+		a.dropped++
+		countDropped(r)
+
+		if a.policy != PolicyDropOldest {
+			a.pending++
+			return nil
+		}
+
+		a.head = (a.head + 1) % len(a.queue)
+		a.count--
+	}
+
+	a.push(r.Clone())
+	return nil
+}
+
+// Dropped returns the number of records dropped by the policy.
+func (a *AsyncSink) Dropped() uint64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return a.dropped
+}
+
+// Close writes the queued records and stops the sink.
+func (a *AsyncSink) Close() error {
+	a.m.Lock()
+	if a.closed {
+		a.m.Unlock()
+		return nil
+	}
+	a.closed = true
+	a.cond.Broadcast()
+	a.m.Unlock()
+
+	<-a.done
+	return nil
+}
+
+// Queue `r`, the lock must be held.
+func (a *AsyncSink) push(r *Record) {
+	a.queue[(a.head+a.count)%len(a.queue)] = r
+	a.count++
+	a.cond.Broadcast()
+}
+
+// Write the queued records until closed and drained.
+func (a *AsyncSink) run() {
+	defer close(a.done)
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for {
+		for a.count == 0 && !a.closed {
+			a.cond.Wait()
+		}
+
+		if a.count == 0 {
+			return
+		}
+
+		r := a.queue[a.head]
+		a.queue[a.head] = nil
+		a.head = (a.head + 1) % len(a.queue)
+		a.count--
+
+		if a.policy == PolicySummarize && a.pending > 0 && a.count < len(a.queue) {
+			a.push(&Record{
+				Time:    time.Now(),
+				Name:    "debug:async",
+				Level:   LevelWarn,
+				Message: "dropped " + strconv.FormatUint(a.pending, 10) + " records",
+			})
+			a.pending = 0
+		}
+
+		a.cond.Broadcast()
+		a.m.Unlock()
+		a.sink.Write(r)
+		a.m.Lock()
+	}
+}
+
+// Count dropped record `r` in the stats of its namespace. The counters are
+// carried by the record rather than looked up by name, since sinks may be
+// written without the package lock.
+func countDropped(r *Record) {
+	if r.stats != nil {
+		r.stats.dropped.Add(1)
+	}
+}
@@ -0,0 +1,181 @@
+package debug
+
+import "strconv"
+import "sync"
+import "testing"
+
+// A sink recording messages once released.
+type gatedSink struct {
+	release chan struct{}
+	m       sync.Mutex
+	got     []string
+}
+
+func (s *gatedSink) Write(r *Record) error {
+	<-s.release
+	s.m.Lock()
+	s.got = append(s.got, r.Message)
+	s.m.Unlock()
+	return nil
+}
+
+func TestAsyncPolicies(t *testing.T) {
+	cases := []struct {
+		policy Policy
+		want   []string
+	}{
+		{PolicyDropNewest, []string{"0", "1", "2"}},
+		{PolicyDropOldest, []string{"0", "3", "4"}},
+		{PolicySummarize, []string{"0", "1", "2", "dropped 2 records"}},
+	}
+
+	for _, c := range cases {
+		s := &gatedSink{release: make(chan struct{})}
+		a := Async(s, AsyncOptions{Size: 2, Policy: c.policy})
+
+		// The first record is taken by the writing goroutine, which then
+		// blocks until released.
+		a.Write(&Record{Message: "0"})
+		for {
+			a.m.Lock()
+			n := a.count
+			a.m.Unlock()
+			if n == 0 {
+				break
+			}
+		}
+
+		for _, msg := range []string{"1", "2", "3", "4"} {
+			a.Write(&Record{Message: msg})
+		}
+
+		if a.Dropped() != 2 {
+			t.Errorf("%s: expected 2 dropped, got %d", c.policy, a.Dropped())
+		}
+
+		close(s.release)
+		a.Close()
+
+		if len(s.got) != len(c.want) {
+			t.Fatalf("%s: expected %v, got %v", c.policy, c.want, s.got)
+		}
+		for i := range c.want {
+			if s.got[i] != c.want[i] {
+				t.Fatalf("%s: expected %v, got %v", c.policy, c.want, s.got)
+			}
+		}
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	s := &gatedSink{release: make(chan struct{})}
+	a := Async(s, AsyncOptions{Size: 1})
+
+	done := make(chan struct{})
+	go func() {
+		for _, msg := range []string{"0", "1", "2"} {
+			a.Write(&Record{Message: msg})
+		}
+		close(done)
+	}()
+
+	close(s.release)
+	<-done
+	a.Close()
+
+	if len(s.got) != 3 || a.Dropped() != 0 {
+		t.Fatalf("unexpected records %v", s.got)
+	}
+
+	if err := a.Write(&Record{}); err == nil {
+		t.Fatalf("expected an error after Close")
+	}
+}
+
+func TestAsyncStats(t *testing.T) {
+	s := &gatedSink{release: make(chan struct{})}
+	a := Async(s, AsyncOptions{Size: 1, Policy: PolicyDropNewest})
+	remove := AddSink(a)
+	defer remove()
+
+	Enable("async:stats")
+	defer Disable()
+
+	log := Named("async:stats")
+	for i := 0; i < 3; i++ {
+		log.Printf("line %d", i)
+	}
+
+	close(s.release)
+	a.Close()
+
+	if st := stat("async:stats"); st.Dropped == 0 || st.Dropped != a.Dropped() {
+		t.Fatalf("unexpected stats %+v, dropped %d", st, a.Dropped())
+	}
+}
+
+func TestAsyncStatsNested(t *testing.T) {
+	s := &gatedSink{release: make(chan struct{})}
+	inner := Async(s, AsyncOptions{Size: 1, Policy: PolicyDropNewest})
+	outer := Async(inner, AsyncOptions{})
+	remove := AddSink(outer)
+	defer remove()
+
+	Enable("async:nested")
+	defer Disable()
+
+	// The inner sink is written without the lock while namespaces are
+	// created.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			Named("async:nested:" + strconv.Itoa(i))
+		}
+	}()
+
+	log := Named("async:nested")
+	before := stat("async:nested").Dropped
+	for i := 0; i < 10; i++ {
+		log.Printf("line %d", i)
+	}
+	<-done
+
+	outer.Close()
+	close(s.release)
+	inner.Close()
+
+	if st := stat("async:nested"); st.Dropped-before != inner.Dropped() || inner.Dropped() == 0 {
+		t.Fatalf("unexpected stats %+v, dropped %d", st, inner.Dropped())
+	}
+}
+
+// A sink formatting records with PrettyFormat.
+type formatSink struct {
+	buf []byte
+}
+
+func (s *formatSink) Write(r *Record) error {
+	s.buf = PrettyFormat.Format(s.buf[:0], r, "")
+	return nil
+}
+
+func TestAsyncFormatting(t *testing.T) {
+	a := Async(&formatSink{}, AsyncOptions{})
+	remove := AddSink(a)
+	defer remove()
+
+	Enable("async:format:*")
+	defer Disable()
+
+	log := Named("async:format:pretty")
+	for i := 0; i < 100; i++ {
+		log.Printf("line %d", i)
+		SetNameWidth(i % 20)
+		SetDurationFormat(Milliseconds(i % 3))
+	}
+
+	a.Close()
+	SetNameWidth(0)
+	SetDurationFormat(nil)
+}
@@ -0,0 +1,98 @@
+package debug
+
+import (
+	"context"
+	"net/http"
+)
+
+// PatternHeader is the HTTP header carrying a pattern from one service to
+// the next, see PropagationTransport and PropagationHandler.
+const PatternHeader = "X-Debug-Namespaces"
+
+// Longest pattern accepted from a request.
+const maxHeaderPattern = 1024
+
+// Context key of the pattern.
+type patternKey struct{}
+
+// A pattern carried by a context.
+type contextPattern struct {
+	str string
+	pat *pattern
+}
+
+// WithPattern returns a copy of `ctx` enabling the namespaces matching
+// `pattern` for calls made with it, such as PrintfContext, in addition to
+// the pattern given to Enable. It is propagated to other services along
+// with requests made with the context, see PropagationTransport.
+func WithPattern(ctx context.Context, pattern string) context.Context {
+	m.Lock()
+	p := compile(pattern, false)
+	m.Unlock()
+
+	return context.WithValue(ctx, patternKey{}, &contextPattern{pattern, p})
+}
+
+// ContextPattern returns the pattern carried by `ctx`, see WithPattern.
+func ContextPattern(ctx context.Context) string {
+	if p, ok := ctx.Value(patternKey{}).(*contextPattern); ok {
+		return p.str
+	}
+	return ""
+}
+
+// Check whether `ctx` carries a pattern matching `name`.
+func contextEnabled(ctx context.Context, name string) bool {
+	p, ok := ctx.Value(patternKey{}).(*contextPattern)
+	return ok && p.pat.match(name)
+}
+
+// PropagationTransport returns a RoundTripper sending the pattern of the
+// request context in the PatternHeader, so that services receiving it
+// with PropagationHandler enable it as well and debug output lights up
+// along the whole call path. It uses http.DefaultTransport when `base` is
+// nil.
+func PropagationTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return roundTripper(func(r *http.Request) (*http.Response, error) {
+		p := ContextPattern(r.Context())
+		if p == "" {
+			return base.RoundTrip(r)
+		}
+
+		r = r.Clone(r.Context())
+		r.Header.Set(PatternHeader, p)
+		return base.RoundTrip(r)
+	})
+}
+
+// A RoundTripper function.
+type roundTripper func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// PropagationHandler returns a handler serving `next` with the pattern of
+// the PatternHeader of requests placed in their context, see WithPattern,
+// when `allow` accepts the request. Patterns are only accepted from
+// trusted callers, such as other internal services, so the header is
+// ignored when `allow` is nil.
+//
+//	h := debug.PropagationHandler(mux, func(r *http.Request) bool {
+//		return internal(r.RemoteAddr)
+//	})
+func PropagationHandler(next http.Handler, allow func(r *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := r.Header.Get(PatternHeader)
+		if p != "" && len(p) <= maxHeaderPattern && allow != nil && allow(r) {
+			r = r.WithContext(WithPattern(r.Context(), p))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,47 @@
+package debug
+
+import "bytes"
+import "context"
+import "net/http"
+import "net/http/httptest"
+import "testing"
+
+func TestPropagation(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "")()
+
+	db := Named("orders:db")
+	backend := httptest.NewServer(PropagationHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		db.PrintfContext(r.Context(), "query for %s", r.URL.Path)
+	}), func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "internal"
+	}))
+	defer backend.Close()
+
+	client := &http.Client{Transport: PropagationTransport(nil)}
+	get := func(ctx context.Context, auth string) {
+		r, _ := http.NewRequestWithContext(ctx, "GET", backend.URL+"/orders", nil)
+		r.Header.Set("Authorization", auth)
+		res, err := client.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	ctx := WithPattern(context.Background(), "orders:*")
+	if p := ContextPattern(ctx); p != "orders:*" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+
+	get(context.Background(), "internal")
+	get(ctx, "external")
+	assertNotContains(t, buf.String(), "query")
+
+	get(ctx, "internal")
+	assertContains(t, buf.String(), "orders:db\033[0m - query for /orders")
+
+	buf.Reset()
+	db.Printf("without context")
+	assertNotContains(t, buf.String(), "without context")
+}
@@ -0,0 +1,19 @@
+package debug
+
+import "time"
+
+// Begin starts timing an operation under namespace `name`. Calling the
+// returned function emits a single line with the printf-style message and
+// the elapsed time as a "duration" field, for example:
+//
+//	done := debug.Begin("cache:refresh")
+//	n := refresh()
+//	done("loaded %d keys", n)
+func Begin(name string) DebugFunction {
+	n := Named(name)
+	start := time.Now()
+
+	return func(format string, args ...interface{}) {
+		n.logOptions(0, LevelDebug, []KV{{"duration", time.Since(start)}}, format, args)
+	}
+}
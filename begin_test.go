@@ -0,0 +1,38 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestBegin(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("cache:*")
+
+	done := Begin("cache:refresh")
+	done("loaded %d keys", 5)
+
+	str := buf.String()
+	assertContains(t, str, "cache:refresh")
+	assertContains(t, str, "loaded 5 keys duration=")
+
+	if c := bytes.Count(buf.Bytes(), []byte("\n")); c != 1 {
+		t.Fatalf("expected a single line, got %d", c)
+	}
+}
+
+func TestBeginDisabled(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Disable()
+
+	done := Begin("cache:refresh")
+	done("loaded %d keys", 5)
+
+	if buf.Len() != 0 {
+		t.Fatalf("buffer should be empty")
+	}
+}
@@ -0,0 +1,95 @@
+// Package breadcrumb records debug output as breadcrumbs of error
+// reporters such as Sentry or Bugsnag, so that reported errors carry the
+// debug context which preceded them. It does not depend on the reporters,
+// the application adds the breadcrumbs to the active hub itself:
+//
+//	debug.AddSink(breadcrumb.Sink(func(b breadcrumb.Breadcrumb) {
+//		sentry.AddBreadcrumb(&sentry.Breadcrumb{
+//			Type:      "debug",
+//			Category:  b.Category,
+//			Message:   b.Message,
+//			Level:     sentry.Level(b.Level),
+//			Data:      b.Data,
+//			Timestamp: b.Time,
+//		})
+//	}))
+//
+// Bugsnag's LeaveBreadcrumb is wired the same way. Only the records of
+// enabled namespaces become breadcrumbs.
+package breadcrumb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tj/go-debug"
+)
+
+// Breadcrumb is a record in the form expected by error reporters.
+type Breadcrumb struct {
+	Time     time.Time
+	Category string
+	Message  string
+	Level    string
+	Data     map[string]interface{}
+}
+
+// Sink returns a debug.Sink calling `add` with a breadcrumb per record.
+// The namespace is the category, and levels are named as by Sentry:
+// "debug", "info", "warning" and "error". It is called with the lock of
+// the debug package held, so it must not write debug output.
+func Sink(add func(Breadcrumb)) debug.Sink {
+	return sink(add)
+}
+
+type sink func(Breadcrumb)
+
+// Write implements debug.Sink.
+func (add sink) Write(r *debug.Record) error {
+	add(New(r))
+	return nil
+}
+
+// New returns the breadcrumb of record `r`. It shares no state with the
+// record, so it may be retained: the message and the string and byte slice
+// values of the fields are copied, as records written with Bytes or Append
+// refer to buffers which are reused.
+func New(r *debug.Record) Breadcrumb {
+	b := Breadcrumb{
+		Time:     r.Time,
+		Category: r.Name,
+		Message:  strings.Clone(r.Message),
+		Level:    level(r.Level),
+	}
+
+	if len(r.Fields) > 0 {
+		b.Data = make(map[string]interface{}, len(r.Fields))
+		for _, f := range r.Fields {
+			b.Data[strings.Clone(f.Key)] = value(f.Value)
+		}
+	}
+
+	return b
+}
+
+// Return a copy of field value `v` which does not alias the record.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case string:
+		return strings.Clone(v)
+	case []byte:
+		return append([]byte(nil), v...)
+	default:
+		return v
+	}
+}
+
+// Return the Sentry name of level `l`.
+func level(l debug.Level) string {
+	if l == debug.LevelWarn {
+		return "warning"
+	}
+	return l.String()
+}
@@ -0,0 +1,50 @@
+package breadcrumb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+func TestSink(t *testing.T) {
+	defer debug.Swap(io.Discard, "checkout:*")()
+
+	var crumbs []Breadcrumb
+	defer debug.AddSink(Sink(func(b Breadcrumb) {
+		crumbs = append(crumbs, b)
+	}))()
+
+	log := debug.Named("checkout:cart")
+	log.Printf("added %d items", 2)
+	log.Warnf("coupon expired")
+	debug.Named("search").Printf("hidden")
+	ctx := debug.WithField(context.Background(), "err", errors.New("declined"))
+	debug.Named("checkout:pay").ErrorfContext(ctx, "payment failed")
+
+	if len(crumbs) != 3 {
+		t.Fatalf("expected 3 breadcrumbs, got %+v", crumbs)
+	}
+
+	if b := crumbs[0]; b.Category != "checkout:cart" || b.Message != "added 2 items" || b.Level != "debug" || b.Time.IsZero() {
+		t.Fatalf("unexpected breadcrumb %+v", b)
+	}
+
+	if b := crumbs[1]; b.Level != "warning" {
+		t.Fatalf("unexpected level %q", b.Level)
+	}
+
+	if b := crumbs[2]; b.Level != "error" || b.Data["err"] != "declined" {
+		t.Fatalf("unexpected breadcrumb %+v", b)
+	}
+
+	// The buffer of Bytes is reused by the caller.
+	buf := []byte("bytes message")
+	log.Bytes(buf)
+	copy(buf, "overwritten!!")
+	if b := crumbs[3]; b.Message != "bytes message" {
+		t.Fatalf("unexpected message %q", b.Message)
+	}
+}
@@ -0,0 +1,65 @@
+package debug
+
+import "time"
+
+// A time budget of a namespace, see Budget.
+type budget struct {
+	limit    time.Duration
+	spent    time.Duration
+	start    time.Time
+	exceeded bool
+}
+
+// Budget attaches a time budget to namespace `name`: once the cumulative
+// time between its records, counted from the call, exceeds `limit`, an
+// error record stating the overrun is written, once. It helps finding
+// which part of a slow start-up made of many steps takes too long:
+//
+//	debug.Budget("startup", 5*time.Second)
+//
+// Calling Budget again starts a new budget, and a limit of zero or less
+// removes it.
+func Budget(name string, limit time.Duration) {
+	n := Named(name)
+
+	m.Lock()
+	defer m.Unlock()
+
+	if limit <= 0 {
+		n.budget = nil
+		return
+	}
+
+	n.budget = &budget{limit: limit, start: time.Now()}
+}
+
+// Account the time since the previous record of the namespace when writing
+// `r`, writing the alarm when the budget is exceeded. The lock must be
+// held.
+func (n *Namespace) spend(r *Record) {
+	b := n.budget
+	if b.exceeded {
+		return
+	}
+
+	d := r.Delta
+	if since := r.Time.Sub(b.start); d > since {
+		d = since
+	}
+
+	b.spent += d
+	if b.spent <= b.limit {
+		return
+	}
+
+	b.exceeded = true
+	n.write(&Record{
+		Time:    r.Time,
+		Name:    n.name,
+		ID:      n.id,
+		Level:   LevelError,
+		Message: "over budget: " + b.spent.String() + " spent of " + b.limit.String(),
+		Fields:  []KV{{"budget", b.limit}, {"spent", b.spent}},
+		Elapsed: r.Elapsed,
+	})
+}
@@ -0,0 +1,37 @@
+package debug
+
+import "bytes"
+import "strings"
+import "testing"
+import "time"
+
+func TestBudget(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "startup")()
+
+	log := Named("startup")
+	Budget("startup", 20*time.Millisecond)
+	defer Budget("startup", 0)
+
+	log.Printf("loading config")
+	time.Sleep(15 * time.Millisecond)
+	log.Printf("connecting")
+	assertNotContains(t, buf.String(), "over budget")
+
+	time.Sleep(15 * time.Millisecond)
+	log.Printf("migrating")
+	time.Sleep(5 * time.Millisecond)
+	log.Printf("ready")
+
+	str := buf.String()
+	assertContains(t, str, "ERR\033[0m - over budget: ")
+	assertContains(t, str, " spent of 20ms budget=20ms spent=")
+	if strings.Count(str, "over budget") != 1 {
+		t.Fatalf("expected a single alarm in %q", str)
+	}
+
+	if i, j := strings.Index(str, "migrating"), strings.Index(str, "over budget"); i > j {
+		t.Fatalf("expected the alarm after the record exceeding the budget")
+	}
+}
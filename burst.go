@@ -0,0 +1,223 @@
+package debug
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Context key of the burst buffer.
+type burstKey struct{}
+
+// A ring of the records buffered for a context.
+type burst struct {
+	pat       *pattern
+	maxAge    time.Duration
+	unbounded bool
+
+	m       sync.Mutex
+	entries []burstEntry
+	next    int
+	scanned int
+	prev    time.Time
+	prevs   map[*Namespace]time.Time
+}
+
+// A buffered record and its namespace.
+type burstEntry struct {
+	n *Namespace
+	r *Record
+}
+
+// WithBurst returns a copy of `ctx` capturing bursts: the last `size`
+// records written with PrintfContext and ErrorfContext by the disabled
+// namespaces matching `pattern` are buffered, and only written when an
+// error is logged with ErrorfContext or on FlushContext. This gives the
+// full context of a failing request at little cost for the ones that
+// succeed:
+//
+//	ctx = debug.WithBurst(ctx, "api:*", 100)
+//	log.PrintfContext(ctx, "parsed %d items", n)
+//	...
+//	log.ErrorfContext(ctx, "failed: %s", err)
+//
+// Flushed records keep their original time.
+func WithBurst(ctx context.Context, pattern string, size int) context.Context {
+	return context.WithValue(ctx, burstKey{}, newBurst(pattern, size, 0))
+}
+
+// WithBurstAge is like WithBurst, however records older than `maxAge`, or
+// than the age set for their namespace by BurstMaxAge, are dropped as
+// well, so that the buffer holds the last moments before an error rather
+// than a fixed number of records. A `size` of zero or less leaves the
+// number of records unbounded, unless `maxAge` is zero or less as well, in
+// which case nothing is buffered.
+//
+//	ctx = debug.WithBurstAge(ctx, "api:*", 0, 30*time.Second)
+func WithBurstAge(ctx context.Context, pattern string, size int, maxAge time.Duration) context.Context {
+	b := newBurst(pattern, size, maxAge)
+	b.unbounded = size <= 0 && maxAge > 0
+	return context.WithValue(ctx, burstKey{}, b)
+}
+
+// BurstMaxAge sets the longest time records of namespace `name` are kept
+// by the burst buffers of WithBurstAge, overriding their own maximum age,
+// for example longer for a namespace of rare but telling records. An age
+// of zero or less removes it.
+func BurstMaxAge(name string, d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	Named(name).burstAge.Store(int64(d))
+}
+
+// Return a burst buffer of the disabled namespaces matching `pattern`.
+func newBurst(pattern string, size int, maxAge time.Duration) *burst {
+	m.Lock()
+	p := compile(pattern, false)
+	m.Unlock()
+
+	if size < 0 {
+		size = 0
+	}
+
+	return &burst{
+		pat:     p,
+		maxAge:  maxAge,
+		entries: make([]burstEntry, 0, size),
+		prevs:   map[*Namespace]time.Time{},
+	}
+}
+
+// FlushContext writes the records buffered for `ctx` by WithBurst, as if
+// an error had been logged.
+func FlushContext(ctx context.Context) {
+	if b := contextBurst(ctx); b != nil {
+		b.flush()
+	}
+}
+
+// Return the burst buffer of `ctx`, if any.
+func contextBurst(ctx context.Context) *burst {
+	b, _ := ctx.Value(burstKey{}).(*burst)
+	return b
+}
+
+// Buffer a record of namespace `n`, dropping the oldest when full.
+func (b *burst) add(n *Namespace, level Level, format, msg string, fields []KV) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if cap(b.entries) == 0 && !b.unbounded {
+		return
+	}
+
+	now := time.Now()
+	r := &Record{
+		Time:    now,
+		Name:    n.name,
+		ID:      n.id,
+		Level:   level,
+		Message: msg,
+		Fields:  fields,
+		Elapsed: elapsed(now),
+
+		Fingerprint: fingerprint(n.name, format),
+	}
+
+	if !b.prev.IsZero() {
+		r.Global = now.Sub(b.prev)
+	}
+	if prev, ok := b.prevs[n]; ok {
+		r.Delta = now.Sub(prev)
+	}
+	b.prev = now
+	b.prevs[n] = now
+
+	e := burstEntry{n, r}
+	if b.unbounded {
+		b.entries = append(b.entries, e)
+		b.expire(now)
+		return
+	}
+
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, e)
+		return
+	}
+
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+}
+
+// Drop the expired records of an unbounded buffer. The oldest ones are
+// dropped while they are expired, and the others once the buffer doubled
+// since the last scan, as records kept longer by BurstMaxAge may precede
+// expired ones. The lock of the buffer must be held.
+func (b *burst) expire(now time.Time) {
+	i := 0
+	for i < len(b.entries) && b.expired(b.entries[i], now) {
+		i++
+	}
+
+	if i > 0 {
+		n := copy(b.entries, b.entries[i:])
+		clear(b.entries[n:])
+		b.entries = b.entries[:n]
+	}
+
+	if len(b.entries) < 2*b.scanned {
+		return
+	}
+
+	kept := b.entries[:0]
+	for _, e := range b.entries {
+		if !b.expired(e, now) {
+			kept = append(kept, e)
+		}
+	}
+	clear(b.entries[len(kept):])
+	b.entries = kept
+	b.scanned = len(kept)
+}
+
+// Check whether entry `e` is older than its maximum age at `now`.
+func (b *burst) expired(e burstEntry, now time.Time) bool {
+	age := time.Duration(e.n.burstAge.Load())
+	if age == 0 {
+		age = b.maxAge
+	}
+	return age > 0 && now.Sub(e.r.Time) > age
+}
+
+// Write and clear the buffered records, oldest first, leaving out the
+// expired ones.
+func (b *burst) flush() {
+	now := time.Now()
+
+	b.m.Lock()
+	entries := make([]burstEntry, 0, len(b.entries))
+	for _, list := range [][]burstEntry{b.entries[b.next:], b.entries[:b.next]} {
+		for _, e := range list {
+			if !b.expired(e, now) {
+				entries = append(entries, e)
+			}
+		}
+	}
+	clear(b.entries)
+	b.entries = b.entries[:0]
+	b.next = 0
+	b.scanned = 0
+	b.m.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, e := range entries {
+		e.n.write(e.r)
+	}
+}
@@ -0,0 +1,112 @@
+package debug
+
+import "bytes"
+import "context"
+import "strings"
+import "testing"
+import "time"
+
+func TestBurst(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("api:errors")
+	defer Disable()
+
+	log := Named("api:handler")
+	errs := Named("api:errors")
+
+	ok := WithBurst(context.Background(), "api:*", 2)
+	log.PrintfContext(ok, "success")
+	if buf.Len() != 0 {
+		t.Fatalf("buffer should be empty")
+	}
+
+	failed := WithBurst(context.Background(), "api:*", 2)
+	log.PrintfContext(failed, "dropped")
+	log.PrintfContext(failed, "first")
+	log.PrintfContext(failed, "second")
+	errs.ErrorfContext(failed, "boom")
+
+	str := buf.String()
+	assertNotContains(t, str, "success")
+	assertNotContains(t, str, "dropped")
+
+	if strings.Index(str, "first") > strings.Index(str, "second") || strings.Index(str, "second") > strings.Index(str, "boom") {
+		t.Fatalf("unexpected order %q", str)
+	}
+
+	buf.Reset()
+	errs.ErrorfContext(failed, "again")
+	assertNotContains(t, buf.String(), "first")
+
+	log.PrintfContext(ok, "flushed")
+	FlushContext(ok)
+	assertContains(t, buf.String(), "success")
+	assertContains(t, buf.String(), "flushed")
+}
+
+func TestBurstAge(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "")()
+
+	log := Named("age:handler")
+	audit := Named("age:audit")
+	BurstMaxAge("age:audit", time.Hour)
+	defer BurstMaxAge("age:audit", 0)
+
+	ctx := WithBurstAge(context.Background(), "age:*", 0, 30*time.Millisecond)
+	audit.PrintfContext(ctx, "login")
+	log.PrintfContext(ctx, "stale")
+	time.Sleep(60 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		log.PrintfContext(ctx, "recent %d", i)
+	}
+	FlushContext(ctx)
+
+	str := buf.String()
+	assertContains(t, str, "login")
+	assertNotContains(t, str, "stale")
+	assertContains(t, str, "recent 0")
+	assertContains(t, str, "recent 2")
+
+	buf.Reset()
+	ctx = WithBurstAge(context.Background(), "age:*", 2, time.Hour)
+	for i := 0; i < 3; i++ {
+		log.PrintfContext(ctx, "bounded %d", i)
+	}
+	FlushContext(ctx)
+	assertNotContains(t, buf.String(), "bounded 0")
+	assertContains(t, buf.String(), "bounded 2")
+
+	// Records behind one kept longer expire all the same, at the latest
+	// once the buffer doubled.
+	ctx = WithBurstAge(context.Background(), "age:*", 0, 5*time.Millisecond)
+	b := contextBurst(ctx)
+	audit.PrintfContext(ctx, "login")
+	for i := 0; i < 20; i++ {
+		log.PrintfContext(ctx, "expired %d", i)
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 50; i++ {
+		log.PrintfContext(ctx, "last")
+	}
+
+	b.m.Lock()
+	for _, e := range b.entries {
+		if strings.HasPrefix(e.r.Message, "expired") {
+			t.Errorf("expected %q to be dropped", e.r.Message)
+		}
+	}
+	b.m.Unlock()
+
+	// Without a size or age nothing is buffered.
+	ctx = WithBurstAge(context.Background(), "age:*", 0, 0)
+	log.PrintfContext(ctx, "unbounded")
+	if b := contextBurst(ctx); len(b.entries) != 0 {
+		t.Fatalf("expected nothing buffered, got %d records", len(b.entries))
+	}
+}
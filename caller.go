@@ -0,0 +1,32 @@
+package debug
+
+import "runtime"
+
+// Annotate `msg` and `fields` using the call site of the logging method,
+// reporting false when the message is a suppressed duplicate, see
+// SetDuplicateWindow and SetModuleFields. `skip` is the number of frames
+// between the method called by user code and the one calling annotate,
+// such as log, so that the call site is found at a fixed depth.
+func (n *Namespace) annotate(skip int, msg string, fields []KV) ([]KV, bool) {
+	dedup, modules := dupActive.Load(), moduleFields.Load()
+	if !dedup && !modules {
+		return fields, true
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(4+skip, pcs[:])
+
+	if dedup {
+		dup, count := n.duplicate(pcs[0], msg)
+		if dup {
+			return nil, false
+		}
+		fields = withDuplicates(fields, count)
+	}
+
+	if modules {
+		fields = withModule(fields, pcs[0])
+	}
+
+	return fields, true
+}
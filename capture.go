@@ -0,0 +1,119 @@
+package debug
+
+import (
+	"bufio"
+	"io"
+	"os"
+	rdebug "runtime/debug"
+	"strings"
+)
+
+// Writers feeding the pipe of CaptureStderr while it captures, and the
+// original standard error replacing them. They are guarded by the lock.
+var (
+	captureFrom []io.Writer
+	captureTo   io.Writer
+)
+
+// Return the original standard error in place of `w` when it writes to the
+// pipe of CaptureStderr, or `w`. The lock must be held.
+func uncaptured(w io.Writer) io.Writer {
+	for _, f := range captureFrom {
+		if w == f {
+			return captureTo
+		}
+	}
+	return w
+}
+
+// Return os.Stderr in place of `w` when it is the original standard error
+// of CaptureStderr, so that configurations saved while capturing remain
+// valid once it is restored. The lock must be held.
+func captured(w io.Writer) io.Writer {
+	if captureTo != nil && w == captureTo {
+		return captureFrom[0]
+	}
+	return w
+}
+
+// CaptureStderr redirects the standard error of the process into a pipe,
+// and re-emits each line written to it under the "stderr" namespace, so
+// that panics and prints from third-party code follow the same pipeline as
+// debug output. While "stderr" is disabled lines are passed through to the
+// original standard error unchanged.
+//
+// Where supported the file descriptor itself is redirected, capturing
+// output from the runtime and cgo. As a crash exits before the pipe can be
+// drained, crash output is also written directly to the original standard
+// error. While capturing, os.Stderr given as the writer, such as by
+// SetWriter, Apply or Swap, is replaced by the original standard error so
+// that captured output does not feed itself, and restoring standard error
+// resets the crash output set with runtime/debug.SetCrashOutput, which
+// cannot be retrieved.
+//
+// Call the returned function to restore standard error, once the lines
+// written before the call have been emitted.
+func CaptureStderr() (restore func(), err error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stderr := os.Stderr
+	orig, undo, err := redirectStderr(w)
+	if err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+
+	rdebug.SetCrashOutput(orig, rdebug.CrashOptions{})
+
+	m.Lock()
+	captureFrom = []io.Writer{stderr, w}
+	captureTo = orig
+	writer = uncaptured(writer)
+	m.Unlock()
+
+	n := Named("stderr")
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line != "" {
+				if n.Enabled() {
+					n.emit(LevelDebug, strings.TrimSuffix(line, "\n"), nil)
+				} else {
+					io.WriteString(orig, line)
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		// Once standard error no longer points at the pipe, closing its
+		// last writer lets the goroutine drain it before ending.
+		undo()
+		w.Close()
+		<-done
+		r.Close()
+
+		m.Lock()
+		if writer == orig {
+			writer = stderr
+		}
+		captureFrom, captureTo = nil, nil
+		m.Unlock()
+
+		rdebug.SetCrashOutput(nil, rdebug.CrashOptions{})
+		if orig != stderr {
+			orig.Close()
+		}
+	}, nil
+}
@@ -0,0 +1,76 @@
+package debug
+
+import "bytes"
+import "fmt"
+import "os"
+import "strings"
+import "testing"
+import "time"
+
+func TestCaptureStderr(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("stderr")
+
+	restore, err := CaptureStderr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Fprintln(os.Stderr, "from a third party")
+	restore()
+
+	assertContains(t, buf.String(), "stderr\033[0m - from a third party")
+}
+
+func TestCaptureStderrLongLines(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("stderr")
+
+	restore, err := CaptureStderr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long := strings.Repeat("x", 100<<10)
+	fmt.Fprintln(os.Stderr, long)
+	fmt.Fprint(os.Stderr, "last line")
+	restore()
+
+	assertContains(t, buf.String(), long)
+	assertContains(t, buf.String(), "stderr\033[0m - last line")
+}
+
+func TestCaptureStderrSwapToStderr(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	restore, err := CaptureStderr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := stat("stderr").Emitted
+	undo := Swap(os.Stderr, "*")
+	fmt.Fprintln(os.Stderr, "captured once")
+
+	// Give a loop time to show up before restoring.
+	for i := 0; i < 100 && stat("stderr").Emitted == before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	undo()
+	restore()
+
+	if n := stat("stderr").Emitted - before; n != 1 {
+		t.Fatalf("expected the captured line to be emitted once, got %d", n)
+	}
+
+	if w := Config().Writer; w == os.Stderr {
+		t.Fatalf("expected the buffer to be restored as the writer")
+	}
+}
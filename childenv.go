@@ -0,0 +1,90 @@
+package debug
+
+import "strings"
+
+// EnvForChild returns environment variables carrying the current pattern,
+// format and color mode, so that child processes inherit the live
+// configuration rather than the DEBUG value the parent started with:
+//
+//	cmd := exec.Command("worker")
+//	cmd.Env = append(os.Environ(), debug.EnvForChild()...)
+//
+// Every variable is set, possibly to an empty value, so that values
+// inherited from the environment of the parent are overridden. Formats
+// other than the built-in ones and those made by Layout, as well as
+// matchers set with SetMatcher, are not carried.
+func EnvForChild() []string {
+	c := Config()
+
+	ordered := ""
+	if c.Ordered {
+		ordered = "1"
+	}
+
+	return []string{
+		"DEBUG=" + c.Pattern,
+		"DEBUG_EXTRA=",
+		"DEBUG_ORDERED=" + ordered,
+		"DEBUG_FORMAT=" + formatName(c.Format),
+		"DEBUG_COLORS=" + c.Color.String(),
+	}
+}
+
+// Apply the DEBUG_FORMAT and DEBUG_COLORS variables, see EnvForChild.
+func envSettings(getenv func(string) string) {
+	if f := parseFormat(getenv("DEBUG_FORMAT")); f != nil {
+		SetFormat(f)
+	}
+
+	switch getenv("DEBUG_COLORS") {
+	case "auto":
+		SetColor(ColorAuto)
+	case "always":
+		SetColor(ColorAlways)
+	case "never":
+		SetColor(ColorNever)
+	}
+}
+
+// Return the name of formatter `f` for DEBUG_FORMAT, or an empty string
+// for formatters which cannot be named.
+func formatName(f Formatter) string {
+	switch f {
+	case TextFormat:
+		return "text"
+	case JSONFormat:
+		return "json"
+	case PrettyFormat:
+		return "pretty"
+	case MsgpackFormat:
+		return "msgpack"
+	}
+
+	if l, ok := f.(layoutFormat); ok {
+		return l.layout
+	}
+
+	return ""
+}
+
+// Return the formatter named `name` by formatName, or nil.
+func parseFormat(name string) Formatter {
+	switch name {
+	case "text":
+		return TextFormat
+	case "json":
+		return JSONFormat
+	case "pretty":
+		return PrettyFormat
+	case "msgpack":
+		return MsgpackFormat
+	}
+
+	if strings.Contains(name, "{") {
+		if f, err := Layout(name); err == nil {
+			return f
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package debug
+
+import "io"
+import "strings"
+import "testing"
+
+func TestEnvForChild(t *testing.T) {
+	defer Swap(io.Discard, "")()
+	defer SetFormat(TextFormat)
+	defer SetColor(ColorAlways)
+
+	EnableOrdered("*,-db:*")
+	layout, _ := Layout("{ns} {msg}")
+	SetFormat(layout)
+	SetColor(ColorNever)
+
+	env := map[string]string{}
+	for _, kv := range EnvForChild() {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+
+	want := map[string]string{
+		"DEBUG":         "*,-db:*",
+		"DEBUG_EXTRA":   "",
+		"DEBUG_ORDERED": "1",
+		"DEBUG_FORMAT":  "{ns} {msg}",
+		"DEBUG_COLORS":  "never",
+	}
+
+	for k, v := range want {
+		if got, ok := env[k]; !ok || got != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got)
+		}
+	}
+
+	SetFormat(TextFormat)
+	SetColor(ColorAlways)
+	envSettings(func(key string) string { return env[key] })
+
+	c := Config()
+	if formatName(c.Format) != "{ns} {msg}" || c.Color != ColorNever {
+		t.Fatalf("expected the settings to be applied, got %v", c)
+	}
+}
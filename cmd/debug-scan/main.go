@@ -0,0 +1,76 @@
+// Command debug-scan scans Go source, not binaries, for the debug
+// namespaces it uses, with the descriptions given to debug.Describe, and
+// lists them as markdown or JSON:
+//
+//	$ debug-scan ./...
+//	| Namespace | Description | Location |
+//	| --- | --- | --- |
+//	| `db:pool` | connection pool checkouts and evictions | db/pool.go:12 |
+//
+// Only namespaces given as string literals to Debug, Named, Describe, V,
+// Begin and Span are found: computed names, and the namespaces derived at
+// run time by Tagged, Group, Tasks and the like are not. The namespaces
+// registered by a running program are listed by debug.Walk and
+// debug.Descriptions, or by starting it with DEBUG=help.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "output JSON instead of markdown")
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"./..."}
+	}
+
+	s := newScanner()
+	for _, dir := range dirs {
+		if err := s.scan(dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var err error
+	if *asJSON {
+		err = writeJSON(os.Stdout, s.namespaces())
+	} else {
+		err = writeMarkdown(os.Stdout, s.namespaces())
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Write `list` as indented JSON.
+func writeJSON(w io.Writer, list []*namespace) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
+
+// Write `list` as a markdown table.
+func writeMarkdown(w io.Writer, list []*namespace) error {
+	var b strings.Builder
+	b.WriteString("| Namespace | Description | Location |\n")
+	b.WriteString("| --- | --- | --- |\n")
+
+	for _, n := range list {
+		doc := strings.ReplaceAll(n.Doc, "|", `\|`)
+		doc = strings.ReplaceAll(doc, "\n", " ")
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", n.Name, doc, strings.Join(n.Locations, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Import path of the debug package.
+const importPath = "github.com/tj/go-debug"
+
+// Functions taking a namespace as their first argument.
+var funcs = map[string]bool{
+	"Debug":    true,
+	"Named":    true,
+	"Describe": true,
+	"V":        true,
+	"Begin":    true,
+	"Span":     true,
+}
+
+// A namespace found in source.
+type namespace struct {
+	Name      string   `json:"name"`
+	Doc       string   `json:"doc,omitempty"`
+	Locations []string `json:"locations"`
+}
+
+// A scanner collects the namespaces of Go files.
+type scanner struct {
+	fset  *token.FileSet
+	found map[string]*namespace
+}
+
+// Return an empty scanner.
+func newScanner() *scanner {
+	return &scanner{
+		fset:  token.NewFileSet(),
+		found: map[string]*namespace{},
+	}
+}
+
+// Scan the Go files of `dir`, and of its subdirectories when it ends with
+// "/...". Hidden, vendor and testdata directories are skipped.
+func (s *scanner) scan(dir string) error {
+	root, recursive := strings.CutSuffix(dir, "/...")
+	if root == "" {
+		root = "."
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+
+			name := d.Name()
+			if !recursive || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		return s.file(path)
+	})
+}
+
+// Collect the namespaces of the file at `path`.
+func (s *scanner) file(path string) error {
+	f, err := parser.ParseFile(s.fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+
+	pkg := ""
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != importPath {
+			continue
+		}
+
+		pkg = "debug"
+		if imp.Name != nil {
+			pkg = imp.Name.Name
+		}
+	}
+
+	if pkg == "" || pkg == "_" {
+		return nil
+	}
+
+	ast.Inspect(f, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		fn, ok := calledFunc(call, pkg)
+		if !ok || !funcs[fn] {
+			return true
+		}
+
+		name, ok := literal(call.Args[0])
+		if !ok {
+			return true
+		}
+
+		n := s.found[name]
+		if n == nil {
+			n = &namespace{Name: name}
+			s.found[name] = n
+		}
+
+		if fn == "Describe" && len(call.Args) > 1 {
+			if doc, ok := literal(call.Args[1]); ok {
+				n.Doc = doc
+			}
+		}
+
+		pos := s.fset.Position(call.Pos())
+		n.Locations = append(n.Locations, fmt.Sprintf("%s:%d", filepath.ToSlash(pos.Filename), pos.Line))
+		return true
+	})
+
+	return nil
+}
+
+// Return the name of the function of package `pkg` called by `call`, which
+// is unqualified when the package is dot-imported.
+func calledFunc(call *ast.CallExpr, pkg string) (string, bool) {
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		x, ok := fun.X.(*ast.Ident)
+		return fun.Sel.Name, ok && x.Name == pkg
+	case *ast.Ident:
+		return fun.Name, pkg == "."
+	default:
+		return "", false
+	}
+}
+
+// Return the namespaces found, sorted by name.
+func (s *scanner) namespaces() []*namespace {
+	list := make([]*namespace, 0, len(s.found))
+	for _, n := range s.found {
+		list = append(list, n)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Return the value of a string literal, including concatenations of them.
+func literal(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := literal(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := literal(e.Y)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return literal(e.X)
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, src string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main.go", `package main
+
+import "github.com/tj/go-debug"
+
+var log = debug.Named("db:pool")
+
+func init() {
+	debug.Describe("db:pool", "connection pool " +
+		"checkouts | evictions")
+	debug.Debug("http")("hello")
+	debug.Debug(name)("dynamic")
+}
+`)
+
+	write("sub/sub.go", `package sub
+
+import d "github.com/tj/go-debug"
+
+var log = d.V("raft", 2)
+var other = debug.Named("ignored")
+`)
+
+	write("sub/dot/dot.go", `package dot
+
+import . "github.com/tj/go-debug"
+
+var log = Named("s3")
+`)
+
+	s := newScanner()
+	if err := s.scan(dir + "/..."); err != nil {
+		t.Fatal(err)
+	}
+
+	list := s.namespaces()
+	if len(list) != 4 || list[0].Name != "db:pool" || list[1].Name != "http" || list[2].Name != "raft" || list[3].Name != "s3" {
+		t.Fatalf("unexpected namespaces %+v", list)
+	}
+
+	if list[0].Doc != "connection pool checkouts | evictions" || len(list[0].Locations) != 2 {
+		t.Fatalf("unexpected namespace %+v", list[0])
+	}
+
+	var b strings.Builder
+	writeMarkdown(&b, list)
+	if !strings.Contains(b.String(), "| `db:pool` | connection pool checkouts \\| evictions | ") {
+		t.Fatalf("unexpected markdown %q", b.String())
+	}
+
+	s = newScanner()
+	s.scan(dir)
+	if len(s.namespaces()) != 2 {
+		t.Fatalf("expected subdirectories to be skipped, got %+v", s.namespaces())
+	}
+}
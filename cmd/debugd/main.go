@@ -0,0 +1,108 @@
+// Command debugd listens for debug output streamed by processes using
+// debug.NetSink and prints it to stderr with per-process prefixes:
+//
+//	$ debugd -listen /tmp/debugd.sock
+//
+// and in each process:
+//
+//	sink, err := debug.Dial("unix", "/tmp/debugd.sock")
+//	...
+//	debug.AddSink(sink)
+//
+// Connections crossing hosts can be secured with TLS, given -tls-cert and
+// -tls-key, requiring client certificates signed by -client-ca when set,
+// or encrypted with a shared key read from -key-file as hexadecimal and
+// passed to the sinks in debug.DialOptions.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/tj/go-debug/debugd"
+)
+
+func main() {
+	network := flag.String("network", "unix", "network to listen on")
+	address := flag.String("listen", "/tmp/debugd.sock", "address to listen on")
+	noColor := flag.Bool("no-color", false, "disable colors")
+	certFile := flag.String("tls-cert", "", "TLS certificate file")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	caFile := flag.String("client-ca", "", "CA file verifying client certificates")
+	secretFile := flag.String("key-file", "", "file with the hexadecimal key of sealed connections")
+	flag.Parse()
+
+	s := &debugd.Server{NoColor: *noColor}
+
+	if *certFile != "" {
+		config, err := tlsConfig(*certFile, *keyFile, *caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.TLS = config
+	}
+
+	if *secretFile != "" {
+		b, err := os.ReadFile(*secretFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		s.Key, err = hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			log.Fatalf("%s: %s", *secretFile, err)
+		}
+	}
+
+	if *network == "unix" {
+		os.Remove(*address)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		if *network == "unix" {
+			os.Remove(*address)
+		}
+		os.Exit(0)
+	}()
+
+	log.Fatal(s.ListenAndServe(*network, *address))
+}
+
+// Load the server certificate and, when `caFile` is set, require client
+// certificates signed by it.
+func tlsConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if caFile == "" {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New(caFile + ": no certificates found")
+	}
+
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
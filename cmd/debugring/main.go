@@ -0,0 +1,88 @@
+// Command debugring prints the records of a ring file written by
+// debug.RingSink, while the process writing it keeps running:
+//
+//	$ debugring /dev/shm/app.ring
+//	$ debugring -f -json /dev/shm/app.ring
+//
+// Records overwritten before they could be read are reported on stderr.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tj/go-debug"
+)
+
+func main() {
+	follow := flag.Bool("f", false, "wait for new records")
+	asJSON := flag.Bool("json", false, "print JSON lines")
+	interval := flag.Duration("interval", 10*time.Millisecond, "polling interval when following")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: debugring [flags] file\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rd, err := debug.OpenRing(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rd.Close()
+
+	format := debug.TextFormat
+	if *asJSON {
+		format = debug.JSONFormat
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	var buf []byte
+	overruns := 0
+	for {
+		r, err := rd.Read()
+		if err == nil {
+			buf = format.Format(buf[:0], r, "")
+			w.Write(buf)
+			continue
+		}
+
+		if err != io.EOF {
+			w.Flush()
+			log.Fatal(err)
+		}
+
+		if n := rd.Overruns(); n > overruns {
+			w.Flush()
+			fmt.Fprintf(os.Stderr, "debugring: records lost %d times\n", n-overruns)
+			overruns = n
+		}
+
+		if !*follow {
+			return
+		}
+		w.Flush()
+
+		select {
+		case <-sig:
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Command debugview is a terminal viewer for debug output, reading records
+// streamed by debug.NetSink, saved by debug.Recorder, or written as JSON
+// lines or MessagePack:
+//
+//	$ debugview -listen /tmp/debugd.sock
+//	$ debugview -file session.rec
+//	$ tail -f session.jsonl | debugview
+//
+// Keys:
+//
+//	space   pause or follow
+//	j k     scroll down and up while paused
+//	/       search, highlighting and showing only matching lines
+//	t       toggle hiding the namespaces matching a glob
+//	c       clear search and hidden namespaces
+//	q       quit
+//
+// Records are read from stdin when no source is given and it is not a
+// terminal. The terminal is put in raw mode with stty, which must be
+// available.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tj/go-debug"
+	"github.com/tj/go-debug/debugd"
+)
+
+func main() {
+	network := flag.String("network", "unix", "network to listen on")
+	address := flag.String("listen", "", "address to listen on for network sinks")
+	file := flag.String("file", "", "file of recorded records, - for stdin")
+	limit := flag.Int("limit", 10000, "number of records kept")
+	flag.Parse()
+
+	v := newView(*limit)
+	failed := make(chan error, 1)
+
+	if *address == "" && *file == "" && !isTerminal(os.Stdin) {
+		*file = "-"
+	}
+
+	switch {
+	case *address != "":
+		if *network == "unix" {
+			os.Remove(*address)
+			defer os.Remove(*address)
+		}
+
+		s := &debugd.Server{
+			Handle: func(hello debug.Hello, r *debug.Record) {
+				v.add(fmt.Sprintf("%s:%d", hello.Process, hello.Pid), r)
+			},
+		}
+
+		go func() {
+			failed <- s.ListenAndServe(*network, *address)
+		}()
+	case *file != "":
+		var r io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		go read(v, r)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Keys are read from the terminal, as stdin may carry the records.
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer tty.Close()
+
+	restore, err := rawMode(tty)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	quit := make(chan struct{})
+	go keys(v, tty, quit)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	rows, cols := size(tty)
+	for tick := 1; ; tick++ {
+		select {
+		case <-quit:
+		case <-sig:
+		case err = <-failed:
+		case <-ticker.C:
+			if tick%20 == 0 {
+				rows, cols = size(tty)
+			}
+			if frame, ok := v.frame(rows, cols); ok {
+				io.WriteString(os.Stdout, frame)
+			}
+			continue
+		}
+		break
+	}
+
+	io.WriteString(os.Stdout, "\033[2J\033[H")
+	restore()
+
+	// Exiting skips the deferred calls, so the terminal is restored first.
+	if err != nil {
+		if *network == "unix" {
+			os.Remove(*address)
+		}
+		log.Fatal(err)
+	}
+}
+
+// Check whether `f` is a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Read records from `r` into `v`.
+func read(v *view, r io.Reader) {
+	rd, err := debug.NewReader(r)
+	if err != nil {
+		return
+	}
+
+	for {
+		rec, err := rd.Read()
+		if err != nil {
+			return
+		}
+		v.add("", rec)
+	}
+}
+
+// Read keys from `tty`, closing `quit` when done.
+func keys(v *view, tty io.Reader, quit chan struct{}) {
+	defer close(quit)
+
+	b := make([]byte, 1)
+	for {
+		if _, err := tty.Read(b); err != nil {
+			return
+		}
+
+		if !v.key(b[0]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Put the terminal `tty` in raw mode without echo, returning a function
+// restoring the previous mode.
+func rawMode(tty *os.File) (restore func(), err error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty(tty, "-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+
+	saved := strings.TrimSpace(state)
+	return func() {
+		stty(tty, saved)
+	}, nil
+}
+
+// Return the rows and columns of `tty`, with defaults when unknown.
+func size(tty *os.File) (rows, cols int) {
+	rows, cols = 24, 80
+
+	out, err := stty(tty, "size")
+	if err != nil {
+		return
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return
+	}
+
+	if r, err := strconv.Atoi(fields[0]); err == nil && r > 0 {
+		rows = r
+	}
+
+	if c, err := strconv.Atoi(fields[1]); err == nil && c > 0 {
+		cols = c
+	}
+
+	return
+}
+
+// Run stty on `tty` with `args`.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tj/go-debug"
+)
+
+// Colors assigned to namespaces by hash.
+var colors = []string{"31", "32", "33", "34", "35", "36"}
+
+// Input modes.
+const (
+	modeNormal = iota
+	modeSearch
+	modeToggle
+)
+
+// A received record with its source.
+type entry struct {
+	source string
+	record *debug.Record
+}
+
+// The state of the viewer.
+type view struct {
+	m       sync.Mutex
+	limit   int
+	entries []entry
+	head    int
+	hidden  []string
+	search  string
+	paused  bool
+	scroll  int
+	mode    int
+	input   string
+	dirty   bool
+}
+
+// Return a view keeping at most `limit` records.
+func newView(limit int) *view {
+	return &view{limit: max(limit, 1), dirty: true}
+}
+
+// Add record `r` received from `source`.
+func (v *view) add(source string, r *debug.Record) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	// Once full, entries are a ring whose oldest one is at head.
+	if len(v.entries) < v.limit {
+		v.entries = append(v.entries, entry{source, r})
+	} else {
+		v.entries[v.head] = entry{source, r}
+		v.head = (v.head + 1) % v.limit
+	}
+
+	if v.paused {
+		v.scroll++
+	}
+
+	v.dirty = true
+}
+
+// Handle key `c`, returning false to quit.
+func (v *view) key(c byte) bool {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.dirty = true
+
+	if v.mode != modeNormal {
+		switch c {
+		case '\r', '\n':
+			if v.mode == modeSearch {
+				v.search = v.input
+			} else if v.input != "" {
+				v.toggle(v.input)
+			}
+			v.mode = modeNormal
+		case 27:
+			v.mode = modeNormal
+		case 127, 8:
+			if v.input != "" {
+				_, size := utf8.DecodeLastRuneInString(v.input)
+				v.input = v.input[:len(v.input)-size]
+			}
+		default:
+			v.input += string(c)
+		}
+		return true
+	}
+
+	switch c {
+	case 'q':
+		return false
+	case ' ':
+		v.paused = !v.paused
+		v.scroll = 0
+	case 'j':
+		if v.scroll > 0 {
+			v.scroll--
+		}
+	case 'k':
+		if v.paused {
+			v.scroll++
+		}
+	case '/':
+		v.mode = modeSearch
+		v.input = ""
+	case 't':
+		v.mode = modeToggle
+		v.input = ""
+	case 'c':
+		v.search = ""
+		v.hidden = nil
+	}
+
+	return true
+}
+
+// Toggle hiding the namespaces matching `glob`.
+func (v *view) toggle(glob string) {
+	for i, h := range v.hidden {
+		if h == glob {
+			v.hidden = append(v.hidden[:i], v.hidden[i+1:]...)
+			return
+		}
+	}
+	v.hidden = append(v.hidden, glob)
+}
+
+// Check whether `e` is shown.
+func (v *view) visible(e entry) bool {
+	for _, h := range v.hidden {
+		if ok, _ := path.Match(h, e.record.Name); ok {
+			return false
+		}
+	}
+
+	return v.search == "" || strings.Contains(plain(e), v.search)
+}
+
+// Return the frame for a terminal of `rows` and `cols`, and whether it
+// changed since the previous call.
+func (v *view) frame(rows, cols int) (string, bool) {
+	v.m.Lock()
+	defer v.m.Unlock()
+
+	if !v.dirty {
+		return "", false
+	}
+	v.dirty = false
+
+	height := rows - 2
+	if height < 1 {
+		height = 1
+	}
+
+	var lines []string
+	skip := v.scroll
+	for i := len(v.entries) - 1; i >= 0 && len(lines) < height; i-- {
+		e := v.entries[(v.head+i)%len(v.entries)]
+		if !v.visible(e) {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		lines = append(lines, v.render(e, cols))
+	}
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	b.WriteString(v.header(cols))
+	b.WriteString("\r\n")
+
+	for i := height - len(lines); i > 0; i-- {
+		b.WriteString("\r\n")
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		b.WriteString(lines[i])
+		b.WriteString("\r\n")
+	}
+
+	b.WriteString(v.footer(cols))
+	return b.String(), true
+}
+
+// Return the status line.
+func (v *view) header(cols int) string {
+	state := "following"
+	if v.paused {
+		state = fmt.Sprintf("paused +%d", v.scroll)
+	}
+
+	s := fmt.Sprintf(" debugview  %d records  %s", len(v.entries), state)
+	if v.search != "" {
+		s += fmt.Sprintf("  search %q", v.search)
+	}
+	if len(v.hidden) > 0 {
+		s += "  hidden " + strings.Join(v.hidden, ",")
+	}
+
+	return "\033[7m" + pad(truncate(s, cols), cols) + "\033[0m"
+}
+
+// Return the prompt or key help.
+func (v *view) footer(cols int) string {
+	switch v.mode {
+	case modeSearch:
+		return truncate("/"+v.input, cols)
+	case modeToggle:
+		return truncate("toggle namespaces: "+v.input, cols)
+	default:
+		return "\033[2m" + truncate("space pause  j/k scroll  / search  t toggle  c clear  q quit", cols) + "\033[0m"
+	}
+}
+
+// Render `e` as a single colored line of at most `cols` columns.
+func (v *view) render(e entry, cols int) string {
+	line := truncate(plain(e), cols)
+	r := e.record
+
+	// The namespace follows the timestamp and source.
+	prefix := r.Time.Format("15:04:05.000") + " "
+	if e.source != "" {
+		prefix += "[" + e.source + "] "
+	}
+
+	rest := strings.TrimPrefix(line, prefix)
+	name := r.Name
+	if !strings.HasPrefix(rest, name) {
+		return highlight(line, v.search)
+	}
+
+	return prefix + "\033[" + color(name) + "m" + name + "\033[0m" + highlight(rest[len(name):], v.search)
+}
+
+// Return the text of `e` without colors.
+func plain(e entry) string {
+	r := e.record
+
+	var b strings.Builder
+	b.WriteString(r.Time.Format("15:04:05.000"))
+	b.WriteString(" ")
+	if e.source != "" {
+		b.WriteString("[" + e.source + "] ")
+	}
+
+	b.WriteString(r.Name)
+	if r.Level != debug.LevelDebug {
+		b.WriteString(" " + r.Level.Badge())
+	}
+
+	b.WriteString(" - ")
+	b.WriteString(strings.Replace(r.Message, "\n", " ⏎ ", -1))
+	for _, f := range r.Fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+
+	return b.String()
+}
+
+// Highlight the occurrences of `search` in `s`.
+func highlight(s, search string) string {
+	if search == "" {
+		return s
+	}
+	return strings.Replace(s, search, "\033[7m"+search+"\033[0m", -1)
+}
+
+// Return the color of namespace `name`.
+func color(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return colors[h.Sum32()%uint32(len(colors))]
+}
+
+// Truncate `s` to `n` columns.
+func truncate(s string, n int) string {
+	if debug.DisplayWidth(s) <= n {
+		return s
+	}
+
+	w := 0
+	for i, r := range s {
+		if w += debug.DisplayWidth(string(r)); w > n {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// Pad `s` with spaces to `n` columns.
+func pad(s string, n int) string {
+	if c := debug.DisplayWidth(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
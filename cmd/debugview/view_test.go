@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/go-debug"
+)
+
+func TestView(t *testing.T) {
+	v := newView(2)
+	now := time.Now()
+
+	v.add("", &debug.Record{Time: now, Name: "db:conn", Message: "dropped"})
+	v.add("", &debug.Record{Time: now, Name: "db:conn", Message: "connected"})
+	v.add("app:1", &debug.Record{Time: now, Name: "http", Message: "GET /"})
+
+	frame, ok := v.frame(10, 80)
+	if !ok {
+		t.Fatalf("expected a frame")
+	}
+
+	if strings.Contains(frame, "dropped") {
+		t.Fatalf("expected the oldest record to be dropped")
+	}
+
+	if !strings.Contains(frame, "[app:1] \033[") || !strings.Contains(frame, "GET /") {
+		t.Fatalf("unexpected frame %q", frame)
+	}
+
+	if _, ok := v.frame(10, 80); ok {
+		t.Fatalf("expected no frame without changes")
+	}
+
+	for _, c := range []byte("tdb:*\r") {
+		v.key(c)
+	}
+
+	frame, _ = v.frame(10, 80)
+	if strings.Contains(frame, "connected") || !strings.Contains(frame, "GET /") {
+		t.Fatalf("expected db:* to be hidden in %q", frame)
+	}
+
+	for _, c := range []byte("c/GET\r") {
+		v.key(c)
+	}
+
+	frame, _ = v.frame(10, 80)
+	if !strings.Contains(frame, "\033[7mGET\033[0m") || strings.Contains(frame, "connected") {
+		t.Fatalf("expected GET to be highlighted in %q", frame)
+	}
+
+	if v.key('q') {
+		t.Fatalf("expected q to quit")
+	}
+}
+
+func TestViewRing(t *testing.T) {
+	v := newView(3)
+	for _, msg := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		v.add("", &debug.Record{Time: time.Now(), Name: "ring", Message: msg})
+	}
+
+	frame, _ := v.frame(10, 80)
+	if strings.Contains(frame, "m2") {
+		t.Fatalf("expected the oldest records to be dropped in %q", frame)
+	}
+
+	i3, i4, i5 := strings.Index(frame, "m3"), strings.Index(frame, "m4"), strings.Index(frame, "m5")
+	if i3 < 0 || i3 > i4 || i4 > i5 {
+		t.Fatalf("expected the records in order in %q", frame)
+	}
+}
@@ -0,0 +1,80 @@
+package debug
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// CommandWriter writes the output of a subprocess line by line, see
+// CommandOutput.
+type CommandWriter struct {
+	m      sync.Mutex
+	n      *Namespace
+	stream string
+	start  time.Time
+	buf    []byte
+}
+
+// CommandOutput returns writers for the Stdout and Stderr of an exec.Cmd
+// which write each line of output as it is printed under namespace
+// `name`, rather than once the command exits. Records carry the "stream"
+// and the time "elapsed" since the call, stderr lines are at LevelWarn:
+//
+//	cmd := exec.Command("make", "build")
+//	cmd.Stdout, cmd.Stderr = debug.CommandOutput("build:make")
+//
+// Output is discarded while the namespace is disabled. Close the writers
+// after the command exits to write a last line missing its newline.
+func CommandOutput(name string) (stdout, stderr *CommandWriter) {
+	n, now := Named(name), time.Now()
+	return &CommandWriter{n: n, stream: "stdout", start: now},
+		&CommandWriter{n: n, stream: "stderr", start: now}
+}
+
+// Write implements io.Writer.
+func (w *CommandWriter) Write(p []byte) (int, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if !w.n.Enabled() {
+		w.buf = w.buf[:0]
+		return len(p), nil
+	}
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.line(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Close writes the pending partial line, if any.
+func (w *CommandWriter) Close() error {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	if len(w.buf) > 0 && w.n.Enabled() {
+		w.line(w.buf)
+	}
+	w.buf = nil
+	return nil
+}
+
+// Write `line` as a record.
+func (w *CommandWriter) line(line []byte) {
+	level := LevelDebug
+	if w.stream == "stderr" {
+		level = LevelWarn
+	}
+
+	fields := []KV{{"stream", w.stream}, {"elapsed", time.Since(w.start)}}
+	w.n.emit(level, string(bytes.TrimSuffix(line, []byte("\r"))), fields)
+}
@@ -0,0 +1,45 @@
+package debug
+
+import "bytes"
+import "fmt"
+import "os/exec"
+import "runtime"
+import "testing"
+
+func TestCommandOutput(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "build:*")()
+
+	stdout, stderr := CommandOutput("build:make")
+	fmt.Fprint(stdout, "compiling\r\nlink")
+	fmt.Fprint(stdout, "ing\npartial")
+	fmt.Fprint(stderr, "warning: unused\n")
+	stdout.Close()
+	stderr.Close()
+
+	str := buf.String()
+	assertContains(t, str, "build:make\033[0m - compiling stream=stdout elapsed=")
+	assertContains(t, str, "build:make\033[0m - linking stream=stdout")
+	assertContains(t, str, "build:make\033[0m - partial stream=stdout")
+	assertContains(t, str, "WRN\033[0m - warning: unused stream=stderr")
+}
+
+func TestCommandOutputExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "build:*")()
+
+	cmd := exec.Command("sh", "-c", "echo one; echo two >&2")
+	cmd.Stdout, cmd.Stderr = CommandOutput("build:sh")
+	if err := cmd.Run(); err != nil {
+		t.Skip(err)
+	}
+
+	assertContains(t, buf.String(), " - one stream=stdout")
+	assertContains(t, buf.String(), " - two stream=stderr")
+}
@@ -0,0 +1,52 @@
+package debug
+
+import "sync"
+
+// CompactFormat returns a Formatter writing records with `f`, JSONFormat
+// or MsgpackFormat, in which the namespace is written as its ID rather
+// than its name, for very high-volume sinks. The first time a namespace
+// is seen, a mapping record carrying both its name and ID and an "event"
+// field of "namespace" is written first. A Decoder restores the names and
+// skips the mapping records. The formatter tracks which namespaces it
+// announced, so use one per stream: sinks do so with EncodingCompactJSON
+// and EncodingCompactMsgpack, see NetSink and Recorder. RingSink does not,
+// as the mapping records would be overwritten.
+func CompactFormat(f Formatter) Formatter {
+	return &compactFormat{f: f, seen: map[uint32]bool{}}
+}
+
+type compactFormat struct {
+	m    sync.Mutex
+	f    Formatter
+	seen map[uint32]bool
+}
+
+// Format implements Formatter.
+func (c *compactFormat) Format(b []byte, r *Record, color string) []byte {
+	if r.ID == 0 {
+		return c.f.Format(b, r, color)
+	}
+
+	c.m.Lock()
+	seen := c.seen[r.ID]
+	c.seen[r.ID] = true
+	c.m.Unlock()
+
+	if !seen {
+		b = c.f.Format(b, &Record{
+			Time:   r.Time,
+			Name:   r.Name,
+			ID:     r.ID,
+			Fields: []KV{{"event", "namespace"}},
+		}, color)
+	}
+
+	compact := *r
+	compact.Name = ""
+	return c.f.Format(b, &compact, color)
+}
+
+// Check whether `r` is a mapping record written by CompactFormat.
+func isMapping(r *Record) bool {
+	return r.Message == "" && len(r.Fields) == 1 && r.Fields[0] == (KV{"event", "namespace"})
+}
@@ -0,0 +1,44 @@
+package debug
+
+import "bytes"
+import "io"
+import "testing"
+
+func TestCompactFormat(t *testing.T) {
+	for _, enc := range []Encoding{EncodingJSON, EncodingMsgpack} {
+		var b []byte
+		buf := bytes.NewBuffer(b)
+		defer Swap(buf, "compact:*")()
+		SetFormat(CompactFormat(enc.Formatter()))
+		defer SetFormat(TextFormat)
+
+		pool, conn := Named("compact:pool"), Named("compact:conn")
+		pool.Printf("one")
+		conn.Printf("two")
+		pool.Printf("three")
+
+		if pool.ID() == 0 || pool.ID() == conn.ID() {
+			t.Fatalf("expected distinct ids, got %d and %d", pool.ID(), conn.ID())
+		}
+
+		if n := bytes.Count(buf.Bytes(), []byte("compact:pool")); n != 1 {
+			t.Fatalf("expected the name once in %s output, got %d", enc, n)
+		}
+
+		dec := NewDecoder(buf, enc)
+		for _, want := range []string{"compact:pool one", "compact:conn two", "compact:pool three"} {
+			r, err := dec.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.Name + " " + r.Message; got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+		}
+
+		if _, err := dec.Decode(); err != io.EOF {
+			t.Fatalf("expected EOF, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package compat provides the API of the original tj/go-debug, with the
+// same signatures and output, for code which depends on them exactly, such
+// as by assigning Disable to a func(). Switching the import
+//
+//	import "github.com/tj/go-debug/compat"
+//
+// keeps such code compiling, while patterns gain the features of the debug
+// package, such as exclusions with "-" and the DEBUG_EXTRA variable.
+// Output goes through the debug package, so its other settings, such as
+// SetFormat, apply as well.
+package compat
+
+import (
+	"io"
+
+	"github.com/tj/go-debug"
+)
+
+// DebugFunction is a debugger function.
+type DebugFunction = debug.DebugFunction
+
+// SetWriter replaces the default of os.Stderr with `w`.
+func SetWriter(w io.Writer) {
+	debug.SetWriter(w)
+}
+
+// Disable all pattern matching. This function is thread-safe.
+func Disable() {
+	debug.Disable()
+}
+
+// Enable the given debug `pattern`. Patterns take a glob-like form,
+// for example if you wanted to enable everything, just use "*", or
+// if you had a library named mongodb you could use "mongodb:connection",
+// or "mongodb:*". Multiple matches can be made with a comma, for
+// example "mongo*,redis*".
+//
+// This function is thread-safe.
+func Enable(pattern string) {
+	debug.Enable(pattern)
+}
+
+// Debug creates a debug function for `name` which you call
+// with printf-style arguments in your application or library.
+func Debug(name string) DebugFunction {
+	return debug.Debug(name)
+}
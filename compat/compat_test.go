@@ -0,0 +1,42 @@
+package compat
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+)
+
+// The signatures of the original package.
+var (
+	_ func(io.Writer)              = SetWriter
+	_ func()                       = Disable
+	_ func(string)                 = Enable
+	_ func(string) DebugFunction   = Debug
+	_ func(string, ...interface{}) = DebugFunction(nil)
+)
+
+// A line written by the original package.
+var line = regexp.MustCompile(`^\d\d:\d\d:\d\d\.\d{3} \d+[mun]?s\s+\033\[3\dm\d+[mun]?s\s+ \033\[3\dmcompat:thing\033\[0m - hello world\n$`)
+
+func TestCompat(t *testing.T) {
+	var buf bytes.Buffer
+	SetWriter(&buf)
+
+	Enable("compat:*,-compat:hidden")
+	defer Disable()
+
+	Debug("compat:thing")("hello %s", "world")
+	Debug("compat:hidden")("hidden")
+
+	if !line.MatchString(buf.String()) {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	Disable()
+	Debug("compat:thing")("disabled")
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,273 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// ColorMode controls the use of terminal colors.
+type ColorMode int
+
+// Color modes.
+const (
+	// ColorAuto uses colors when writing to a terminal.
+	ColorAuto ColorMode = iota
+
+	// ColorAlways uses colors regardless of the writer, the default.
+	ColorAlways
+
+	// ColorNever disables colors.
+	ColorNever
+)
+
+// Settings is a snapshot of the effective configuration, see Config and
+// Apply. An empty Pattern means output is disabled unless a Matcher is
+// set, and Ordered selects the evaluation of EnableOrdered.
+type Settings struct {
+	Pattern string
+	Ordered bool
+	Matcher Matcher
+	Writer  io.Writer
+	Format  Formatter
+	Color   ColorMode
+	Sync    bool
+}
+
+// String returns a description of the configuration.
+func (c Settings) String() string {
+	return fmt.Sprintf("pattern=%q writer=%s format=%T color=%s sync=%v", c.Pattern, describeWriter(c.Writer), c.Format, c.Color, c.Sync)
+}
+
+// String returns the name of the mode.
+func (c ColorMode) String() string {
+	switch c {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	default:
+		return fmt.Sprintf("ColorMode(%d)", int(c))
+	}
+}
+
+// SetFormat replaces the default of TextFormat with `f`.
+func SetFormat(f Formatter) {
+	m.Lock()
+	defer m.Unlock()
+	formatter = f
+}
+
+// Name width and separator of namespace segments, read without the lock by
+// formatters, see SetNameWidth and SetSeparator.
+var (
+	nameWidth atomic.Int32
+	separator atomic.Pointer[string]
+)
+
+// SetNameWidth abbreviates namespaces longer than `width` in human output,
+// see Abbreviate. Structured formats always carry the full name. A width
+// of zero, the default, disables abbreviation.
+func SetNameWidth(width int) {
+	nameWidth.Store(int32(width))
+}
+
+// SetSeparator sets the separator of namespace segments, ":" by default,
+// for teams using other conventions such as "/" or ".". It is used by
+// Extend, by Abbreviate, and by patterns, in which "db.*" matches "db" as
+// well as its descendants with a "." separator. The current pattern, such
+// as the one of the DEBUG environment variable, is recompiled with the new
+// separator. An empty separator is ignored.
+func SetSeparator(sep string) {
+	if sep == "" {
+		return
+	}
+
+	m.Lock()
+	defer unlock()
+
+	if sep == Separator() {
+		return
+	}
+	separator.Store(&sep)
+
+	if prev := active.Load(); prev != nil {
+		next := compile(current, ordered)
+		next.custom = prev.custom
+		setActive(next)
+	}
+}
+
+// Separator returns the separator of namespace segments.
+func Separator() string {
+	if sep := separator.Load(); sep != nil {
+		return *sep
+	}
+	return ":"
+}
+
+// SetSync enables synchronous writes: after each line the writer is
+// flushed if it buffers output, and synced to disk if it has a Sync method
+// such as *os.File. This is slow, but ensures the last lines before a
+// crash are not lost.
+func SetSync(sync bool) {
+	m.Lock()
+	defer m.Unlock()
+	syncWrites = sync
+}
+
+// SetLevelPrefixes enables parsing the level of lines written at
+// LevelDebug from a prefix of their message, easing the migration of
+// existing call sites to leveled output. The prefix is removed, and may be
+// a level name or badge followed by a colon, in brackets, or after an
+// exclamation mark:
+//
+//	debug("ERROR: connection lost")
+//	debug("[warn] retrying")
+//	debug("!err timed out")
+func SetLevelPrefixes(on bool) {
+	m.Lock()
+	defer m.Unlock()
+	prefixes = on
+}
+
+// SetColor sets the color mode, the default is ColorAlways.
+func SetColor(mode ColorMode) {
+	m.Lock()
+	defer m.Unlock()
+	colorMode = mode
+	colored = useColor(mode, writer)
+}
+
+// Config returns the effective configuration, which may later be
+// restored with Apply, for example around tests or subcommands.
+func Config() Settings {
+	m.Lock()
+	defer m.Unlock()
+	return config()
+}
+
+// Return the current configuration, the lock must be held.
+func config() Settings {
+	c := Settings{
+		Writer: captured(writer),
+		Format: formatter,
+		Color:  colorMode,
+		Sync:   syncWrites,
+	}
+
+	if p := active.Load(); p != nil {
+		c.Pattern = current
+		c.Ordered = ordered
+		c.Matcher = p.custom
+	}
+
+	return c
+}
+
+// Apply replaces the configuration with `c` in one step. A nil Writer or
+// Format is replaced by the default.
+func Apply(c Settings) {
+	m.Lock()
+	defer unlock()
+	apply(c)
+}
+
+// Replace the configuration with `c`, the lock must be held.
+func apply(c Settings) {
+	if c.Writer == nil {
+		c.Writer = defaultWriter
+	}
+	c.Writer = uncaptured(c.Writer)
+
+	if c.Format == nil {
+		c.Format = TextFormat
+	}
+
+	if c.Writer != writer {
+		flush(writer)
+	}
+
+	writer = c.Writer
+	formatter = c.Format
+	colorMode = c.Color
+	syncWrites = c.Sync
+	colored = useColor(c.Color, c.Writer)
+	current = c.Pattern
+	ordered = c.Ordered
+	var p *pattern
+	if c.Pattern != "" || c.Matcher != nil {
+		p = compile(c.Pattern, c.Ordered)
+		p.custom = c.Matcher
+	}
+	setActive(p)
+	generation++
+}
+
+// Swap replaces the writer with `w` and the pattern with `pattern`,
+// removing the conditions added with EnableWhen, and returns a function
+// restoring the previous state. An empty pattern disables output. It lets
+// tests isolate themselves from the global state:
+//
+//	var buf bytes.Buffer
+//	defer debug.Swap(&buf, "db:*")()
+func Swap(w io.Writer, pattern string) (restore func()) {
+	m.Lock()
+	defer unlock()
+
+	prev, prevConditions := config(), conditions
+
+	c := prev
+	c.Writer = w
+	c.Pattern = pattern
+	c.Ordered = false
+	c.Matcher = nil
+	apply(c)
+	conditions = nil
+
+	return func() {
+		m.Lock()
+		defer unlock()
+		apply(prev)
+		conditions = prevConditions
+	}
+}
+
+// Check whether colors should be used for `w` in `mode`.
+func useColor(mode ColorMode, w io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return isTerminal(w)
+	default:
+		return false
+	}
+}
+
+// Check whether `w` is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isatty(f)
+}
+
+// Return a short description of `w`.
+func describeWriter(w io.Writer) string {
+	switch w {
+	case nil:
+		return "none"
+	case os.Stderr:
+		return "stderr"
+	case os.Stdout:
+		return "stdout"
+	}
+
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+
+	return fmt.Sprintf("%T", w)
+}
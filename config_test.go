@@ -0,0 +1,143 @@
+package debug
+
+import "bytes"
+import "os"
+import "testing"
+
+func TestConfigApply(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	Enable("config:*")
+
+	saved := Config()
+	if saved.Pattern != "config:*" || saved.Writer != buf {
+		t.Fatalf("unexpected config %v", saved)
+	}
+
+	Apply(Settings{Pattern: "other", Writer: os.Stdout, Format: JSONFormat, Color: ColorNever})
+	Apply(saved)
+
+	Debug("config:test")("restored")
+
+	str := buf.String()
+	assertContains(t, str, "config:test")
+	assertContains(t, str, "\033[")
+}
+
+func TestSwap(t *testing.T) {
+	var b []byte
+	outer := bytes.NewBuffer(b)
+	SetWriter(outer)
+	Enable("outer")
+	remove := EnableWhen("inner", "tenant", "acme")
+	defer remove()
+
+	inner := bytes.NewBuffer(nil)
+	restore := Swap(inner, "inner")
+	Debug("inner")("swapped")
+	Debug("outer")("hidden")
+	restore()
+
+	Debug("outer")("restored")
+	Debug("inner")("hidden")
+
+	assertContains(t, inner.String(), "swapped")
+	assertNotContains(t, inner.String(), "hidden")
+	assertContains(t, outer.String(), "restored")
+	assertNotContains(t, outer.String(), "hidden")
+
+	if c := Config(); c.Pattern != "outer" || len(conditions) != 1 {
+		t.Fatalf("expected the state to be restored, got %v", c)
+	}
+}
+
+func TestDisabledConfig(t *testing.T) {
+	Enable("foo")
+	Disable()
+
+	if c := Config(); c.Pattern != "" {
+		t.Fatalf("expected empty pattern, got %q", c.Pattern)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+
+	Enable("json")
+	Begin("json")("loaded %q", "x")
+
+	str := buf.String()
+	assertContains(t, str, `"name":"json"`)
+	assertContains(t, str, `"message":"loaded \"x\""`)
+	assertContains(t, str, `"duration":`)
+}
+
+func TestColorNever(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	SetColor(ColorNever)
+	defer SetColor(ColorAlways)
+
+	Enable("plain")
+	Debug("plain")("text")
+
+	assertNotContains(t, buf.String(), "\033[")
+}
+
+type syncBuffer struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (b *syncBuffer) Sync() error {
+	b.syncs++
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	buf := &syncBuffer{}
+	SetWriter(buf)
+	SetSync(true)
+	defer SetSync(false)
+
+	Enable("sync")
+	Debug("sync")("one")
+	Debug("sync")("two")
+
+	if buf.syncs != 2 {
+		t.Fatalf("expected 2 syncs, got %d", buf.syncs)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	if isTerminal(null) {
+		t.Fatalf("expected %s not to be a terminal", os.DevNull)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if isTerminal(w) {
+		t.Fatal("expected a pipe not to be a terminal")
+	}
+
+	if isTerminal(bytes.NewBuffer(nil)) {
+		t.Fatal("expected a buffer not to be a terminal")
+	}
+}
@@ -0,0 +1,68 @@
+package debug
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// Whether conflicting registrations are reported, see SetConflictWarnings.
+var conflictWarnings = os.Getenv("DEBUG_CONFLICTS") != ""
+
+// Path of this package, whose frames are skipped to find callers.
+var selfPackage = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	return packagePath(runtime.FuncForPC(pc).Name())
+}()
+
+// SetConflictWarnings reports namespaces used by two different packages,
+// which makes filtering them ambiguous. The first time a namespace is
+// used by another package than the one which registered it, a warning
+// with both locations is written, whether the namespace is enabled or
+// not. Namespaces registered by package variables are only tracked when
+// warnings are turned on by the DEBUG_CONFLICTS environment variable,
+// since those are initialized before main runs.
+func SetConflictWarnings(on bool) {
+	m.Lock()
+	defer m.Unlock()
+	conflictWarnings = on
+}
+
+// Record the origin of a use of the namespace from package `pkg` at
+// `location`, returning a warning if it conflicts with the first one. The
+// lock must be held.
+func (n *Namespace) checkOrigin(pkg, location string) string {
+	if pkg == "" {
+		return ""
+	}
+
+	if n.origin == "" {
+		n.origin, n.location = pkg, location
+		return ""
+	}
+
+	if pkg == n.origin || n.conflicted {
+		return ""
+	}
+
+	n.conflicted = true
+	return "namespace used by " + n.origin + " at " + n.location + " and by " + pkg + " at " + location
+}
+
+// Return the package and location of the first caller outside of this
+// package.
+func callerOrigin() (pkg, location string) {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+
+	for {
+		frame, more := frames.Next()
+		if p := packagePath(frame.Function); p != selfPackage && p != "" {
+			return p, frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+
+		if !more {
+			return "", ""
+		}
+	}
+}
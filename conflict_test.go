@@ -0,0 +1,54 @@
+package debug
+
+import "bytes"
+import "strings"
+import "testing"
+
+func TestCheckOrigin(t *testing.T) {
+	n := &Namespace{name: "db"}
+
+	if w := n.checkOrigin("example.com/a", "a.go:1"); w != "" {
+		t.Fatalf("unexpected warning %q", w)
+	}
+
+	if w := n.checkOrigin("example.com/a", "a.go:7"); w != "" {
+		t.Fatalf("unexpected warning %q", w)
+	}
+
+	w := n.checkOrigin("example.com/b", "b.go:3")
+	if w != "namespace used by example.com/a at a.go:1 and by example.com/b at b.go:3" {
+		t.Fatalf("unexpected warning %q", w)
+	}
+
+	if w := n.checkOrigin("example.com/c", "c.go:3"); w != "" {
+		t.Fatalf("expected a single warning, got %q", w)
+	}
+}
+
+func TestConflictWarnings(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	SetConflictWarnings(true)
+	defer SetConflictWarnings(false)
+
+	// Callers in this package are skipped, finding the testing package.
+	Named("conflict")
+
+	pkg, location := callerOrigin()
+	if pkg != "testing" || !strings.Contains(location, "testing.go:") {
+		t.Fatalf("unexpected origin %q %q", pkg, location)
+	}
+
+	m.Lock()
+	names["conflict"].origin = "example.com/other"
+	m.Unlock()
+
+	Named("conflict")
+	Named("conflict")
+	if n := strings.Count(buf.String(), "namespace used by example.com/other"); n != 1 {
+		t.Fatalf("expected one warning, got %q", buf.String())
+	}
+	assertContains(t, buf.String(), "and by testing at ")
+}
@@ -0,0 +1,71 @@
+//go:build js && wasm
+
+package debug
+
+import (
+	"io"
+	"syscall/js"
+)
+
+// Console writes to the browser console, the default writer when compiled
+// to js/wasm. Records are written with console.debug, info, warn or error
+// by level, with the namespace styled in its color like the debug package
+// of node. Bytes written directly are logged as is.
+var Console io.Writer = console{}
+
+// CSS colors of the terminal colors.
+var cssColors = map[string]string{
+	"31": "#d33",
+	"32": "#3a3",
+	"33": "#c90",
+	"34": "#36c",
+	"35": "#b3b",
+	"36": "#299",
+}
+
+type console struct{}
+
+func init() {
+	defaultWriter = Console
+	writer = Console
+}
+
+// Write implements io.Writer.
+func (console) Write(b []byte) (int, error) {
+	js.Global().Get("console").Call("debug", string(b))
+	return len(b), nil
+}
+
+// Write `r` with the namespace styled in `color`. The name and message are
+// arguments of the format rather than part of it, so that their `%` are not
+// taken for directives.
+func (console) writeRecord(r *Record, color string) {
+	b := make([]byte, 0, 64+len(r.Message))
+	b = append(b, r.Message...)
+	b = appendFields(b, r.Fields)
+	b = append(b, " +"...)
+	b = appendHumanizeNano(b, r.Delta.Nanoseconds())
+
+	var args []interface{}
+	if css, ok := cssColors[color]; ok {
+		args = []interface{}{"%c%s%c %s", "color: " + css + "; font-weight: bold", r.Name, "color: inherit", string(b)}
+	} else {
+		args = []interface{}{"%s %s", r.Name, string(b)}
+	}
+
+	js.Global().Get("console").Call(consoleMethod(r.Level), args...)
+}
+
+// Return the console method of `level`.
+func consoleMethod(level Level) string {
+	switch level {
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,159 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+)
+
+// Context key of the fields.
+type fieldsKey struct{}
+
+// WithField returns a copy of `ctx` carrying the field `key` with `value`.
+// Context fields are added to the records written with PrintfContext, and
+// are matched by EnableWhen.
+func WithField(ctx context.Context, key string, value interface{}) context.Context {
+	prev := ContextFields(ctx)
+	fields := make([]KV, len(prev), len(prev)+1)
+	copy(fields, prev)
+	fields = append(fields, KV{key, value})
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// ContextFields returns the fields carried by `ctx`.
+func ContextFields(ctx context.Context) []KV {
+	fields, _ := ctx.Value(fieldsKey{}).([]KV)
+	return fields
+}
+
+// Return the latest value of field `key` in `ctx`.
+func contextField(ctx context.Context, key string) (interface{}, bool) {
+	fields := ContextFields(ctx)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// A condition added by EnableWhen.
+type condition struct {
+	pat   *pattern
+	key   string
+	value string
+}
+
+// EnableWhen enables the namespaces matching `pattern` for calls made with
+// PrintfContext whose context field `key` equals `value`, regardless of
+// the pattern given to Enable. For example to debug a single customer:
+//
+//	debug.EnableWhen("api:*", "tenant", "acme")
+//	...
+//	ctx = debug.WithField(ctx, "tenant", "acme")
+//	log.PrintfContext(ctx, "handling %s", r.URL)
+//
+// Values are compared by their string form. Call the returned function to
+// remove the condition, or Disable to remove all of them.
+func EnableWhen(pattern, key string, value interface{}) (remove func()) {
+	m.Lock()
+	defer m.Unlock()
+
+	c := &condition{
+		pat:   compile(pattern, false),
+		key:   key,
+		value: fmt.Sprint(value),
+	}
+	conditions = append(conditions, c)
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, v := range conditions {
+			if v == c {
+				conditions = append(conditions[:i:i], conditions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// PrintfContext is like Printf, adding the fields of `ctx` to the record.
+// It also writes when the namespace is enabled for `ctx` by EnableWhen or
+// WithPattern, and buffers the record when `ctx` captures bursts, see
+// WithBurst.
+func (n *Namespace) PrintfContext(ctx context.Context, format string, args ...interface{}) {
+	n.logContext(0, ctx, LevelDebug, nil, format, args...)
+}
+
+// ErrorfContext is like PrintfContext at LevelError. It flushes the records
+// buffered for `ctx` by WithBurst first.
+func (n *Namespace) ErrorfContext(ctx context.Context, format string, args ...interface{}) {
+	n.logContext(0, ctx, LevelError, nil, format, args...)
+}
+
+// Format and write a line at `level` with the fields of `ctx` followed by
+// `fields`. `skip` is as for log.
+func (n *Namespace) logContext(skip int, ctx context.Context, level Level, fields []KV, format string, args ...interface{}) {
+	if reentrant() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	b := contextBurst(ctx)
+	if len(fields) > 0 {
+		ctxFields := ContextFields(ctx)
+		fields = append(ctxFields[:len(ctxFields):len(ctxFields)], fields...)
+	} else {
+		fields = ContextFields(ctx)
+	}
+
+	if !n.EnabledContext(ctx) {
+		if b != nil && b.pat.match(n.name) {
+			b.add(n, level, format, fmt.Sprintf(format, args...), fields)
+		} else {
+			n.stats.suppressed.Add(1)
+		}
+
+		if b != nil && level == LevelError {
+			b.flush()
+		}
+		return
+	}
+
+	if b != nil && level == LevelError {
+		b.flush()
+	}
+
+	msg := fmt.Sprintf(format, args...)
+	fields, ok := n.annotate(skip, msg, fields)
+	if !ok {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	n.emitFormat(level, format, msg, fields)
+}
+
+// EnabledContext reports whether output is enabled for the namespace in
+// `ctx`, see EnableWhen and WithPattern.
+func (n *Namespace) EnabledContext(ctx context.Context) bool {
+	if n.Enabled() || contextEnabled(ctx, n.name) {
+		return true
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, c := range conditions {
+		if !c.pat.match(n.name) {
+			continue
+		}
+
+		if v, ok := contextField(ctx, c.key); ok && fmt.Sprint(v) == c.value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package debug
+
+import "bytes"
+import "context"
+import "testing"
+
+func TestEnableWhen(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Disable()
+	remove := EnableWhen("api:*", "tenant", "acme")
+	defer remove()
+
+	log := Named("api:users")
+	acme := WithField(context.Background(), "tenant", "acme")
+	other := WithField(context.Background(), "tenant", "globex")
+
+	log.PrintfContext(acme, "for acme")
+	log.PrintfContext(other, "for globex")
+	log.Printf("without context")
+	Named("db").PrintfContext(acme, "other namespace")
+
+	str := buf.String()
+	assertContains(t, str, "for acme tenant=acme")
+	assertNotContains(t, str, "globex")
+	assertNotContains(t, str, "without context")
+	assertNotContains(t, str, "other namespace")
+
+	remove()
+	buf.Reset()
+	log.PrintfContext(acme, "removed")
+
+	if buf.Len() != 0 {
+		t.Fatalf("buffer should be empty")
+	}
+}
+
+func TestWithField(t *testing.T) {
+	ctx := WithField(context.Background(), "a", 1)
+	a := WithField(ctx, "b", 2)
+	b := WithField(ctx, "b", 3)
+
+	if len(ContextFields(a)) != 2 || ContextFields(a)[1].Value != 2 || ContextFields(b)[1].Value != 3 {
+		t.Fatalf("unexpected fields %v and %v", ContextFields(a), ContextFields(b))
+	}
+}
@@ -0,0 +1,73 @@
+package debug
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Most shards of a shardedCounter.
+const maxShards = 16
+
+// A counter padded to a cache line, so that shards do not share one.
+type paddedCounter struct {
+	n atomic.Uint64
+	_ [56]byte
+}
+
+// A counter updated by many goroutines at once, such as the calls to
+// disabled namespaces in hot paths of concurrent services. Updates are
+// spread over shards, up to one per P, which are summed when read, so that
+// goroutines running on different CPUs do not contend for one cache line.
+// The shards are allocated on the first update.
+type shardedCounter struct {
+	shards atomic.Pointer[[]paddedCounter]
+}
+
+// Add `delta` to the shard of the calling goroutine.
+func (c *shardedCounter) Add(delta uint64) {
+	shards := c.shards.Load()
+	if shards == nil {
+		shards = c.init()
+	}
+
+	s := *shards
+	s[shard(len(s))].n.Add(delta)
+}
+
+// Load returns the sum of the shards.
+func (c *shardedCounter) Load() uint64 {
+	shards := c.shards.Load()
+	if shards == nil {
+		return 0
+	}
+
+	var sum uint64
+	for i := range *shards {
+		sum += (*shards)[i].n.Load()
+	}
+	return sum
+}
+
+// Allocate the shards, a power of two for GOMAXPROCS.
+func (c *shardedCounter) init() *[]paddedCounter {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) && n < maxShards {
+		n *= 2
+	}
+
+	s := make([]paddedCounter, n)
+	if c.shards.CompareAndSwap(nil, &s) {
+		return &s
+	}
+	return c.shards.Load()
+}
+
+// Pick one of `n` shards, a power of two, for the calling goroutine. Go
+// does not expose the P running a goroutine, so the address of its stack
+// is hashed instead, which differs between goroutines and rarely changes.
+func shard(n int) int {
+	var b byte
+	p := uint64(uintptr(unsafe.Pointer(&b))) >> 10
+	return int((p * 0x9e3779b97f4a7c15) >> 32 & uint64(n-1))
+}
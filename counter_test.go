@@ -0,0 +1,44 @@
+package debug
+
+import "bytes"
+import "runtime"
+import "sync"
+import "testing"
+
+func TestShardedCounter(t *testing.T) {
+	var c shardedCounter
+	if c.Load() != 0 {
+		t.Fatalf("expected zero, got %d", c.Load())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := c.Load(); n != 8000 {
+		t.Fatalf("expected 8000, got %d", n)
+	}
+
+	if n := len(*c.shards.Load()); n&(n-1) != 0 || n > maxShards || n < min(runtime.GOMAXPROCS(0), maxShards) {
+		t.Fatalf("unexpected number of shards %d", n)
+	}
+}
+
+func BenchmarkSuppressed(b *testing.B) {
+	defer Swap(bytes.NewBuffer(nil), "")()
+	log := Named("bench:suppressed")
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			log.Printf("hidden")
+		}
+	})
+}
@@ -3,26 +3,55 @@ package debug
 import (
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
-	"regexp"
+	"sort"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	writer  io.Writer = os.Stderr
-	reg     *regexp.Regexp
-	m       sync.Mutex
-	enabled = false
+	defaultWriter io.Writer = os.Stderr
+	writer                  = defaultWriter
+	active        atomic.Pointer[pattern]
+	ordered       bool
+	m             sync.Mutex
+	current       string
+	names         = map[string]*Namespace{}
+	formatter     = TextFormat
+	colorMode     = ColorAlways
+	colored       = true
+	syncWrites    = false
+	prefixes      = false
+	out           []byte
+	recordPool    = sync.Pool{New: func() interface{} { return new(Record) }}
+	sinks         []*sinkEntry
+	conditions    []*condition
+	generation    int
 )
 
 // Debugger function.
 type DebugFunction func(string, ...interface{})
 
-// Terminal colors used at random.
+// Debugger hands out debug functions for namespaces. Libraries may accept
+// a Debugger rather than calling Debug directly, so that tests can swap in
+// the recording implementation from the fake subpackage.
+type Debugger interface {
+	Debug(name string) DebugFunction
+}
+
+// Default is the Debugger backed by the package-level functions.
+var Default Debugger = defaultDebugger{}
+
+type defaultDebugger struct{}
+
+// Debug implements Debugger.
+func (defaultDebugger) Debug(name string) DebugFunction {
+	return Debug(name)
+}
+
+// Terminal colors of namespaces, see colorOf.
 var colors []string = []string{
 	"31",
 	"32",
@@ -32,82 +61,484 @@ var colors []string = []string{
 	"36",
 }
 
-// Initialize with DEBUG environment variable.
+// Initialize with the DEBUG environment variables, see envPattern and
+// PrintUsage for DEBUG=help.
 func init() {
-	env := os.Getenv("DEBUG")
+	env, help := stripHelp(envPattern(os.Getenv, "DEBUG", binaryName()))
+	if help {
+		printStartupHelp()
+	}
 
+	envSettings(os.Getenv)
 	if "" != env {
-		Enable(env)
+		enable(env, os.Getenv("DEBUG_ORDERED") != "")
 	}
 }
 
-// SetWriter replaces the default of os.Stderr with `w`.
-func SetWriter(w io.Writer) {
+// SetWriter replaces the default of os.Stderr with `w`, returning the
+// previous writer. Lines written before the call are drained first: when
+// the previous writer buffers output, such as a bufio.Writer, it is
+// flushed before switching.
+func SetWriter(w io.Writer) io.Writer {
 	m.Lock()
 	defer m.Unlock()
+
+	w = uncaptured(w)
+	prev := writer
+	flush(prev)
+	if f, ok := prev.(*fileRouter); ok && prev != w {
+		f.Close()
+	}
 	writer = w
+	colored = useColor(colorMode, w)
+	return captured(prev)
+}
+
+// Implemented by writers which render records themselves rather than
+// writing the output of the formatter, such as the browser console.
+type recordWriter interface {
+	writeRecord(r *Record, color string)
+}
+
+// Implemented by writers sending the output of each namespace to another
+// writer, such as the one of SetRoute.
+type outputRouter interface {
+	route(name string) io.Writer
+}
+
+// Implemented by writers which buffer output.
+type flusher interface {
+	Flush() error
+}
+
+// Implemented by writers which can commit output to stable storage.
+type syncer interface {
+	Sync() error
+}
+
+// Flush `w` if it buffers output.
+func flush(w io.Writer) {
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// Flush `w` and commit it to stable storage when supported.
+func syncWriter(w io.Writer) {
+	flush(w)
+	if s, ok := w.(syncer); ok {
+		s.Sync()
+	}
 }
 
-// Disable all pattern matching. This function is thread-safe.
-func Disable() {
+// Disable all pattern matching, including conditions added with
+// EnableWhen. Given patterns, only the namespaces they match are disabled
+// instead, on top of the current pattern, for example Disable("db:*")
+// after Enable("*") enables everything but db.
+//
+// This function is thread-safe.
+func Disable(patterns ...string) {
 	m.Lock()
-	defer m.Unlock()
-	enabled = false
+	defer unlock()
+
+	if len(patterns) == 0 {
+		setActive(nil)
+		conditions = nil
+		generation++
+		return
+	}
+
+	prev := active.Load()
+	if prev == nil {
+		return
+	}
+
+	p := current
+	for _, str := range patterns {
+		for _, tok := range tokens(str) {
+			if p != "" {
+				p += ","
+			}
+			p += "-" + tok
+		}
+	}
+
+	next := compile(p, ordered)
+	next.custom = prev.custom
+	setActive(next)
+	current = p
+	generation++
 }
 
 // Enable the given debug `pattern`. Patterns take a glob-like form,
 // for example if you wanted to enable everything, just use "*", or
 // if you had a library named mongodb you could use "mongodb:connection",
 // or "mongodb:*". Multiple matches can be made with a comma, for
-// example "mongo*,redis*".
+// example "mongo*,redis*". Prefixing a name with "-" disables it, for
+// example "mongo*,-mongo:pool" enables all of mongo except the pool.
 //
 // This function is thread-safe.
 func Enable(pattern string) {
+	enable(pattern, false)
+}
+
+// EnableOrdered is like Enable, however tokens are evaluated in order and
+// the last one matching a name decides whether it is enabled. For example
+// "*,-db:*,db:pool" enables everything except the db namespaces, but
+// re-enables the db pool.
+//
+// This function is thread-safe.
+func EnableOrdered(pattern string) {
+	enable(pattern, true)
+}
+
+// EnableFor is like Enable, however the previous pattern is restored after
+// `d`, so verbose output turned on in production shuts itself off. The
+// previous pattern is not restored when the pattern is changed again in
+// the meantime.
+//
+// This function is thread-safe.
+func EnableFor(pattern string, d time.Duration) {
+	m.Lock()
+	defer unlock()
+
+	prevPat, prevCurrent, prevOrdered := active.Load(), current, ordered
+	setPattern(pattern, false)
+	gen := generation
+
+	time.AfterFunc(d, func() {
+		m.Lock()
+		defer unlock()
+
+		if generation != gen {
+			return
+		}
+
+		setActive(prevPat)
+		current, ordered = prevCurrent, prevOrdered
+		generation++
+	})
+}
+
+// Enable `pattern` with the given evaluation order.
+func enable(pattern string, order bool) {
+	m.Lock()
+	defer unlock()
+	setPattern(pattern, order)
+}
+
+// Set the current pattern, the lock must be held. The compiled pattern is
+// replaced as a whole rather than modified, so that Enabled can load it
+// without the lock while other goroutines, such as parallel tests, change
+// the configuration.
+func setPattern(pattern string, order bool) {
+	setActive(compile(pattern, order))
+	current = pattern
+	ordered = order
+	generation++
+}
+
+// Namespaces returns the sorted names of all registered namespaces.
+func Namespaces() []string {
 	m.Lock()
 	defer m.Unlock()
-	pattern = regexp.QuoteMeta(pattern)
-	pattern = strings.Replace(pattern, "\\*", ".*?", -1)
-	pattern = strings.Replace(pattern, ",", "|", -1)
-	pattern = "^(" + pattern + ")$"
-	reg = regexp.MustCompile(pattern)
-	enabled = true
+	return sortedNames()
+}
+
+// Preview reports which registered namespaces would be toggled by
+// enabling `pattern`, without applying it. The pattern is evaluated in
+// the same order mode as the current one. Both slices are sorted.
+func Preview(pattern string) (enabledNames, disabledNames []string) {
+	m.Lock()
+	defer m.Unlock()
+
+	pat, next := active.Load(), compile(pattern, ordered)
+	for name := range names {
+		was := pat != nil && pat.match(name)
+		now := next.match(name)
+		switch {
+		case now && !was:
+			enabledNames = append(enabledNames, name)
+		case was && !now:
+			disabledNames = append(disabledNames, name)
+		}
+	}
+
+	sort.Strings(enabledNames)
+	sort.Strings(disabledNames)
+	return
 }
 
 // Debug creates a debug function for `name` which you call
 // with printf-style arguments in your application or library.
+//
+// Trailing arguments left over by the format which are an Option set the
+// level and fields of the line, see Option. The returned function cannot
+// be checked by go vet's printf analyzer, use Named for debuggers which
+// can, with options given to With.
 func Debug(name string) DebugFunction {
-	prevGlobal := time.Now()
-	color := colors[rand.Intn(len(colors))]
-	prev := time.Now()
+	return Named(name).debugf
+}
 
-	return func(format string, args ...interface{}) {
-		if !enabled {
-			return
-		}
+// Namespace is a named debugger. Unlike a DebugFunction its printf-style
+// methods are recognised by go vet, which verifies format strings and
+// arguments, and options are given to With instead:
+//
+//	var log = debug.Named("mongo:conn")
+//	log.Printf("connected to %s", addr)
+//	log.With(debug.LevelWarn).Printf("retrying %s", addr)
+//
+// All debuggers of the same name share one Namespace.
+type Namespace struct {
+	name       string
+	doc        string
+	color      string
+	prevGlobal time.Time
+	prev       time.Time
+	stats      counters
+	budget     *budget
+	id         uint32
+	always     bool
+	callers    []uintptr
+	burstAge   atomic.Int64
 
-		if !reg.MatchString(name) {
-			return
-		}
+	// The first registration, see SetConflictWarnings.
+	origin     string
+	location   string
+	conflicted bool
+}
+
+// Named returns the namespace for `name`, registering it on first use.
+func Named(name string) *Namespace {
+	locked := lockUnlessWriting()
+	n, ok := names[name]
+	if !ok {
+		n = newNamespace(name)
+		n.id = uint32(len(names) + 1)
+		n.callers = creators()
+		names[name] = n
+		registered(name)
+		registeredHelp(name)
+	}
+
+	warning := ""
+	if conflictWarnings {
+		warning = n.checkOrigin(callerOrigin())
+	}
+	if locked {
+		unlock()
+	}
+
+	if warning != "" {
+		n.emit(LevelWarn, warning, nil)
+	}
+
+	return n
+}
+
+// Return a namespace for `name` without registering it. Namespaces are
+// mostly declared by package variables and never enabled, so their color
+// and times are only set up by their first line.
+func newNamespace(name string) *Namespace {
+	return &Namespace{name: name}
+}
+
+// Return the color of the namespace, picked by its first line. The lock
+// must be held.
+func (n *Namespace) colorCode() string {
+	if n.color == "" {
+		n.color = colorOf(n.name)
+	}
+	return n.color
+}
+
+// Return the color of namespace `name`, derived from an FNV-1a hash of it
+// so that a namespace keeps its color across runs, and programs seeding
+// the global random generator are not perturbed.
+func colorOf(name string) string {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return colors[h%uint32(len(colors))]
+}
+
+// Printf writes a line with printf-style arguments if the namespace is
+// enabled. Options are given with With rather than as arguments, so that
+// go vet checks every argument against the format.
+func (n *Namespace) Printf(format string, args ...interface{}) {
+	n.log(0, LevelDebug, nil, format, args...)
+}
+
+// Extend returns the child namespace `name`, joined to the name of `n` by
+// the separator, for example "mongo:conn" extended with "pool" becomes
+// "mongo:conn:pool".
+func (n *Namespace) Extend(name string) *Namespace {
+	return Named(n.name + Separator() + name)
+}
+
+// Infof is like Printf at LevelInfo.
+func (n *Namespace) Infof(format string, args ...interface{}) {
+	n.log(0, LevelInfo, nil, format, args...)
+}
+
+// Warnf is like Printf at LevelWarn.
+func (n *Namespace) Warnf(format string, args ...interface{}) {
+	n.log(0, LevelWarn, nil, format, args...)
+}
+
+// Errorf is like Printf at LevelError.
+func (n *Namespace) Errorf(format string, args ...interface{}) {
+	n.log(0, LevelError, nil, format, args...)
+}
+
+// Name returns the name of the namespace.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// ID returns the small integer identifying the namespace within the
+// process, assigned in order of registration from 1. It is written to
+// structured output, and lets CompactFormat omit the name.
+func (n *Namespace) ID() uint32 {
+	return n.id
+}
+
+// Enabled reports whether output is enabled for the namespace, which
+// callers can use to skip expensive argument preparation.
+func (n *Namespace) Enabled() bool {
+	if n.always {
+		return true
+	}
+
+	pat := active.Load()
+	return pat != nil && pat.match(n.name)
+}
 
-		d := deltas(prevGlobal, prev, color)
-		fmt.Fprintf(writer, d+" \033["+color+"m"+name+"\033[0m - "+format+"\n", args...)
-		prevGlobal = time.Now()
-		prev = time.Now()
+// Format and write a line at `level` with `fields` if the namespace is
+// enabled. `skip` is the number of frames between the method called by
+// user code and log, see annotate.
+func (n *Namespace) log(skip int, level Level, fields []KV, format string, args ...interface{}) {
+	if !n.Enabled() {
+		n.stats.suppressed.Add(1)
+		return
 	}
+
+	msg := fmt.Sprintf(format, args...)
+	fields, ok := n.annotate(skip, msg, fields)
+	if !ok {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	n.emitFormat(level, format, msg, fields)
+}
+
+// Write `msg` and `fields` as a record of the namespace.
+func (n *Namespace) emit(level Level, msg string, fields []KV) {
+	n.emitFormat(level, "", msg, fields)
 }
 
-// Return formatting for deltas.
-func deltas(prevGlobal, prev time.Time, color string) string {
+// Write `msg` formatted from `format` with `fields` as a record of the
+// namespace, fingerprinted by the format.
+func (n *Namespace) emitFormat(level Level, format, msg string, fields []KV) {
+	if reentrant() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if prefixes && level == LevelDebug {
+		level, msg = levelPrefix(msg)
+	}
+
+	if len(processFields) > 0 {
+		fields = append(fields[:len(fields):len(fields)], processFields...)
+	}
+
 	now := time.Now()
-	global := now.Sub(prevGlobal).Nanoseconds()
-	delta := now.Sub(prev).Nanoseconds()
-	ts := now.UTC().Format("15:04:05.000")
-	deltas := fmt.Sprintf("%s %-6s \033["+color+"m%-6s", ts, humanizeNano(global), humanizeNano(delta))
-	return deltas
+	if n.prev.IsZero() {
+		n.prevGlobal, n.prev = now, now
+	}
+
+	r := recordPool.Get().(*Record)
+	*r = Record{
+		Time:    now,
+		Name:    n.name,
+		ID:      n.id,
+		Level:   level,
+		Message: msg,
+		Fields:  fields,
+		Global:  now.Sub(n.prevGlobal),
+		Delta:   now.Sub(n.prev),
+		Elapsed: elapsed(now),
+
+		Fingerprint: fingerprint(n.name, format),
+	}
+
+	n.write(r)
+	if n.budget != nil {
+		n.spend(r)
+	}
+
+	*r = Record{}
+	recordPool.Put(r)
+	n.prevGlobal = now
+	n.prev = now
+}
+
+// Write record `r` of the namespace to the writer and sinks, the lock must
+// be held.
+func (n *Namespace) write(r *Record) {
+	writing.Store(true)
+	defer writing.Store(false)
+
+	color := ""
+	if colored {
+		color = n.colorCode()
+	}
+
+	msg := r.Message
+	if colored && len(decorators) > 0 {
+		r.Message = decorate(r)
+	}
+
+	if rw, ok := writer.(recordWriter); ok {
+		rw.writeRecord(r, color)
+	} else {
+		out = formatter.Format(out[:0], r, color)
+		if !mute(out) {
+			w := writer
+			if rw, ok := writer.(outputRouter); ok {
+				w = rw.route(r.Name)
+			}
+			writeOutput(w, out)
+		}
+		n.stats.bytes.Add(uint64(len(out)))
+	}
+	r.Message = msg
+
+	n.stats.emitted.Add(1)
+	if syncWrites {
+		syncWriter(writer)
+	}
+
+	r.stats = &n.stats
+	for _, s := range sinks {
+		s.Write(r)
+	}
 }
 
 // Humanize nanoseconds to a string.
 func humanizeNano(n int64) string {
+	return string(appendHumanizeNano(nil, n))
+}
+
+// Append humanized nanoseconds.
+func appendHumanizeNano(b []byte, n int64) []byte {
 	var suffix string
 
 	switch {
@@ -124,5 +555,6 @@ func humanizeNano(n int64) string {
 		suffix = "ns"
 	}
 
-	return strconv.Itoa(int(n)) + suffix
+	b = strconv.AppendInt(b, n, 10)
+	return append(b, suffix...)
 }
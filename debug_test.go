@@ -1,9 +1,12 @@
 package debug
 
 import "testing"
+import "bufio"
+import "io"
 import "strings"
 import "bytes"
 import "time"
+import "strconv"
 
 func assertContains(t *testing.T, str, substr string) {
 	if !strings.Contains(str, substr) {
@@ -117,6 +120,120 @@ func TestEnableDisable(t *testing.T) {
 	}
 }
 
+func TestDisablePattern(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Disable("foo")
+	Debug("foo")("while disabled")
+
+	EnableOrdered("*")
+	Disable("foo", "bar baz")
+
+	Debug("foo")("foo")
+	Debug("baz")("baz")
+	Debug("qux")("qux")
+
+	str := buf.String()
+	assertNotContains(t, str, "while disabled")
+	assertNotContains(t, str, "foo")
+	assertNotContains(t, str, "baz")
+	assertContains(t, str, "qux")
+
+	if c := Config(); c.Pattern != "*,-foo,-bar,-baz" || !c.Ordered {
+		t.Fatalf("unexpected configuration %v", c)
+	}
+
+	Disable()
+}
+
+func TestEnableFor(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("foo")
+	EnableFor("foo,bar", 20*time.Millisecond)
+
+	bar := Debug("bar")
+	bar("during")
+	assertContains(t, buf.String(), "during")
+
+	time.Sleep(50 * time.Millisecond)
+	bar("after")
+	assertNotContains(t, buf.String(), "after")
+
+	Debug("foo")("still")
+	assertContains(t, buf.String(), "still")
+
+	// A pattern set in the meantime is kept.
+	EnableFor("bar", 20*time.Millisecond)
+	Enable("bar")
+	time.Sleep(50 * time.Millisecond)
+	bar("kept")
+	assertContains(t, buf.String(), "kept")
+
+	Disable()
+}
+
+// Parallel tests changing the configuration must not race, the records
+// of each test may land in the writer of another one however.
+func TestConcurrentConfig(t *testing.T) {
+	t.Cleanup(Swap(io.Discard, ""))
+
+	for i := 0; i < 4; i++ {
+		name := "parallel:" + string(rune('a'+i))
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var b []byte
+			buf := bytes.NewBuffer(b)
+			log := Named(name)
+			for j := 0; j < 100; j++ {
+				SetWriter(buf)
+				Enable(name)
+				log.Printf("line %d", j)
+				log.Verbose(1)
+				Disable()
+			}
+
+			m.Lock()
+			defer m.Unlock()
+			for _, line := range strings.SplitAfter(buf.String(), "\n") {
+				if line != "" && !strings.HasSuffix(line, "\n") {
+					t.Fatalf("unexpected partial line %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("preview:a")
+
+	Debug("preview:a")
+	Debug("preview:b")
+
+	on, off := Preview("preview:b,other")
+
+	if len(on) != 1 || on[0] != "preview:b" {
+		t.Fatalf("expected preview:b to be enabled, got %v", on)
+	}
+
+	if len(off) != 1 || off[0] != "preview:a" {
+		t.Fatalf("expected preview:a to be disabled, got %v", off)
+	}
+
+	if !active.Load().match("preview:a") {
+		t.Fatalf("preview should not apply the pattern")
+	}
+}
+
 func ExampleEnable() {
 	Enable("mongo:connection")
 	Enable("mongo:*")
@@ -150,3 +267,81 @@ func BenchmarkNonMatch(b *testing.B) {
 		debug("stuff")
 	}
 }
+
+func BenchmarkDebug(b *testing.B) {
+	names := make([]string, b.N)
+	for i := range names {
+		names[i] = "bench:" + strconv.Itoa(i)
+	}
+
+	b.ResetTimer()
+	for _, name := range names {
+		Debug(name)
+	}
+}
+
+func TestColorOf(t *testing.T) {
+	if colorOf("db:conn") != colorOf("db:conn") {
+		t.Fatal("expected a stable color")
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		seen[colorOf("ns:"+strconv.Itoa(i))] = true
+	}
+
+	if len(seen) != len(colors) {
+		t.Fatalf("expected every color to be used, got %v", seen)
+	}
+}
+
+func TestNamedLazy(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "lazy")()
+
+	n := Named("lazy")
+	if n.color != "" || !n.prev.IsZero() {
+		t.Fatal("expected the namespace to be set up by its first line")
+	}
+
+	n.Printf("hello")
+	if n.color == "" || n.prev.IsZero() {
+		t.Fatal("expected the first line to set up the namespace")
+	}
+}
+
+func TestSetWriterDrains(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	w := bufio.NewWriter(buf)
+	SetWriter(w)
+
+	Enable("drain")
+	Debug("drain")("buffered")
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected output to be buffered")
+	}
+
+	prev := SetWriter(io.Discard)
+	if prev != w {
+		t.Fatalf("expected the previous writer to be returned")
+	}
+
+	assertContains(t, buf.String(), "buffered")
+}
+
+func TestNamed(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("named")
+
+	log := Named("named")
+	if log != Named("named") || log.Name() != "named" || !log.Enabled() {
+		t.Fatalf("unexpected namespace %v", log)
+	}
+
+	log.Printf("value %d", 5)
+	assertContains(t, buf.String(), "named\033[0m - value 5")
+}
@@ -0,0 +1,64 @@
+// Package debugcli wires a --debug pattern flag into CLI frameworks such as
+// cobra and urfave/cli, without depending on them. With cobra:
+//
+//	root.PersistentFlags().Var(debugcli.Value(), "debug", debugcli.Usage)
+//	root.RegisterFlagCompletionFunc("debug", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+//		return debugcli.Complete(toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+//	})
+//
+// With urfave/cli:
+//
+//	&cli.GenericFlag{Name: "debug", Value: debugcli.Value(), Usage: debugcli.Usage}
+package debugcli
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/tj/go-debug"
+)
+
+// Usage is the usage text of the flag.
+const Usage = "enable debug output for the comma-separated namespace pattern"
+
+// Value returns a flag value applying patterns with debug.Enable. It
+// implements flag.Value, pflag.Value and urfave/cli's Generic.
+func Value() *debug.PatternValue {
+	return &debug.PatternValue{}
+}
+
+// Complete returns the completions of a partially typed pattern: the
+// registered namespaces and their "parent:*" globs which extend the last
+// token of `toComplete`, keeping any earlier tokens and a leading "-".
+func Complete(toComplete string) []string {
+	head := ""
+	last := toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		head, last = toComplete[:i+1], toComplete[i+1:]
+	}
+
+	if strings.HasPrefix(last, "-") {
+		head += "-"
+		last = last[1:]
+	}
+
+	sep := debug.Separator()
+	seen := map[string]bool{}
+	for _, name := range debug.Namespaces() {
+		segments := strings.Split(name, sep)
+		for i := 1; i < len(segments); i++ {
+			seen[strings.Join(segments[:i], sep)+sep+"*"] = true
+		}
+		seen[name] = true
+	}
+
+	var list []string
+	for c := range seen {
+		if strings.HasPrefix(c, last) {
+			list = append(list, head+c)
+		}
+	}
+
+	sort.Strings(list)
+	return list
+}
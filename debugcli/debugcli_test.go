@@ -0,0 +1,41 @@
+package debugcli
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+func TestComplete(t *testing.T) {
+	debug.Named("cli:mongo:conn")
+	debug.Named("cli:mongo:pool")
+	debug.Named("cli:redis")
+
+	got := Complete("cli:m")
+	want := []string{"cli:mongo:*", "cli:mongo:conn", "cli:mongo:pool"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got = Complete("cli:redis,-cli:mongo:p")
+	want = []string{"cli:redis,-cli:mongo:pool"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(Value(), "debug", Usage)
+	defer debug.Disable()
+
+	if err := fs.Parse([]string{"-debug", "cli:*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !debug.Named("cli:redis").Enabled() {
+		t.Fatalf("expected the flag to enable the pattern")
+	}
+}
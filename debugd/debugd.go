@@ -0,0 +1,170 @@
+// Package debugd multiplexes the debug output of several local processes,
+// streamed with debug.NetSink, onto a single writer. Each line is prefixed
+// with the name and pid of the process it came from.
+package debugd
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tj/go-debug"
+)
+
+// Largest hello line accepted from a connection.
+const maxHello = 64 << 10
+
+// Terminal colors assigned to processes in turn.
+var colors = []string{
+	"36",
+	"35",
+	"34",
+	"33",
+	"32",
+	"31",
+}
+
+// Server accepts NetSink connections.
+type Server struct {
+	// Output receives the multiplexed lines, os.Stderr when nil.
+	Output io.Writer
+
+	// Format renders records, debug.TextFormat when nil.
+	Format debug.Formatter
+
+	// NoColor disables colors.
+	NoColor bool
+
+	// Handle, when set, receives the records instead of Output. It is
+	// called from one goroutine per connection.
+	Handle func(hello debug.Hello, r *debug.Record)
+
+	// TLS, when set, makes ListenAndServe accept TLS connections. Set its
+	// ClientAuth and ClientCAs to require client certificates.
+	TLS *tls.Config
+
+	// Key, when set, decrypts connections sealed with the same key, see
+	// debug.DialOptions.
+	Key []byte
+
+	m     sync.Mutex
+	count int
+	buf   []byte
+}
+
+// ListenAndServe listens on `network` and `address` and serves the
+// connections.
+func (s *Server) ListenAndServe(network, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	if s.TLS != nil {
+		l = tls.NewListener(l, s.TLS)
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on `l` until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// Read the hello line and the records of a connection.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if s.Key != nil {
+		sealed, err := debug.SealConn(conn, s.Key)
+		if err != nil {
+			return
+		}
+		conn = sealed
+	}
+
+	// The hello line must fit in the buffer, so that peers sending no
+	// newline cannot exhaust memory. Records are bounded by the decoder.
+	r := bufio.NewReaderSize(conn, maxHello)
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return
+	}
+
+	var hello debug.Hello
+	if err := json.Unmarshal(line, &hello); err != nil {
+		return
+	}
+
+	s.m.Lock()
+	color := colors[s.count%len(colors)]
+	s.count++
+	s.m.Unlock()
+
+	prefix := fmt.Sprintf("[%s:%d]", hello.Process, hello.Pid)
+	if !s.NoColor {
+		prefix = "\033[" + color + "m" + prefix + "\033[0m"
+	}
+
+	if s.NoColor {
+		color = ""
+	}
+
+	dec := debug.NewDecoder(r, hello.Encoding)
+	for {
+		rec, err := dec.Decode()
+		if err != nil {
+			return
+		}
+
+		if s.Handle != nil {
+			s.Handle(hello, rec)
+			continue
+		}
+
+		s.write(prefix, rec, color)
+	}
+}
+
+// Format `r` and write each of its lines with `prefix`.
+func (s *Server) write(prefix string, r *debug.Record, color string) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	f := s.Format
+	if f == nil {
+		f = debug.TextFormat
+	}
+
+	w := s.Output
+	if w == nil {
+		w = os.Stderr
+	}
+
+	s.buf = f.Format(s.buf[:0], r, color)
+	lines := strings.SplitAfter(string(s.buf), "\n")
+
+	var out strings.Builder
+	for _, line := range lines {
+		if line != "" {
+			out.WriteString(prefix + " " + line)
+		}
+	}
+
+	io.WriteString(w, out.String())
+}
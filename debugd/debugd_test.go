@@ -0,0 +1,200 @@
+package debugd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj/go-debug"
+)
+
+// Writer safe for concurrent use.
+type buffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.b.String()
+}
+
+func TestServer(t *testing.T) {
+	testServer(t, &Server{}, debug.DialOptions{Encoding: debug.EncodingJSON})
+}
+
+func TestServerMsgpack(t *testing.T) {
+	testServer(t, &Server{}, debug.DialOptions{Encoding: debug.EncodingMsgpack})
+}
+
+func TestServerSealed(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	testServer(t, &Server{Key: key}, debug.DialOptions{Key: key})
+}
+
+func TestServerTLS(t *testing.T) {
+	cert, pool := certificate(t)
+
+	s := &Server{TLS: &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}}
+
+	testServer(t, s, debug.DialOptions{TLS: &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   "127.0.0.1",
+	}})
+}
+
+func TestServerWrongKey(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var handled sync.WaitGroup
+	handled.Add(1)
+	out := &buffer{}
+	s := &Server{Output: out, NoColor: true, Key: bytes.Repeat([]byte{1}, 16)}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+		handled.Done()
+	}()
+
+	sink, err := debug.DialWith("tcp", l.Addr().String(), debug.DialOptions{Key: bytes.Repeat([]byte{2}, 16)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Write(&debug.Record{Time: time.Now(), Name: "remote:thing", Message: "hello"})
+	handled.Wait()
+
+	if str := out.String(); str != "" {
+		t.Fatalf("expected no output, got %q", str)
+	}
+}
+
+func testServer(t *testing.T, s *Server, opts debug.DialOptions) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if s.TLS != nil {
+		l = tls.NewListener(l, s.TLS)
+	}
+
+	out := &buffer{}
+	s.Output = out
+	s.NoColor = true
+	go s.Serve(l)
+
+	sink, err := debug.DialWith("tcp", l.Addr().String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	sink.Write(&debug.Record{
+		Time:    time.Now(),
+		Name:    "remote:thing",
+		Message: "hello",
+		Fields:  []debug.KV{{Key: "n", Value: 1}},
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "hello") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	str := out.String()
+	if !strings.Contains(str, "remote:thing - hello n=1") {
+		t.Fatalf("unexpected output %q", str)
+	}
+
+	if !strings.HasPrefix(str, "[debugd.test:") {
+		t.Fatalf("expected process prefix in %q", str)
+	}
+}
+
+// Return a self-signed certificate for 127.0.0.1, used as both server and
+// client certificate, and a pool trusting it.
+func certificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "debugd"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv, Leaf: leaf}, pool
+}
+
+func TestServerLongHello(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan struct{})
+	go func() {
+		(&Server{Output: &buffer{}}).handle(server)
+		close(done)
+	}()
+
+	// The server hangs up before the whole line is written.
+	client.Write(bytes.Repeat([]byte{'x'}, maxHello+1))
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the connection to be dropped")
+	}
+}
@@ -0,0 +1,64 @@
+// Package debugtest helps debugging tests with the debug package: each
+// test gets a namespace of its own, enabled for its duration, whose output
+// goes to the test log.
+package debugtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+// Namespace returns the namespace of test `t`, "test" followed by the
+// separator and the name of the test, such as "test:TestFoo/subcase", and
+// enables it until the end of the test, along with `patterns`. Output is
+// written to the test log with debug.SetTB, so it is only shown when the
+// test fails or with go test -v:
+//
+//	func TestFlaky(t *testing.T) {
+//		log := debugtest.Namespace(t, "db:*")
+//		log.Printf("retrying %d", n)
+//	}
+//
+// The configuration is restored once the test ends. As it is shared,
+// output of parallel tests may be attributed to the wrong one.
+func Namespace(t testing.TB, patterns ...string) *debug.Namespace {
+	t.Helper()
+
+	prev := debug.Config()
+	t.Cleanup(func() {
+		debug.Apply(prev)
+	})
+
+	n := debug.Named("test" + debug.Separator() + t.Name())
+
+	c := prev
+	c.Pattern = strings.Join(append(patterns, quote(n.Name())), ",")
+	if prev.Pattern != "" {
+		c.Pattern = prev.Pattern + "," + c.Pattern
+	}
+	debug.Apply(c)
+
+	debug.SetTB(t)
+	return n
+}
+
+// Return a pattern token matching exactly `name`, escaping the characters
+// of globs and separators.
+func quote(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if strings.ContainsRune(`\*?{}, `, r) || (i == 0 && r == '-') {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	// Keep a trailing "=N" of the name from being taken for a verbosity.
+	if strings.Contains(name, "=") {
+		b.WriteString("=0")
+	}
+
+	return b.String()
+}
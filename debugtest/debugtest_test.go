@@ -0,0 +1,42 @@
+package debugtest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+func TestNamespace(t *testing.T) {
+	var buf bytes.Buffer
+	defer debug.Swap(&buf, "other")()
+
+	t.Run("n=2, {x}", func(t *testing.T) {
+		log := Namespace(t, "db:*")
+		if log.Name() != "test:TestNamespace/n=2,_{x}" {
+			t.Fatalf("unexpected name %q", log.Name())
+		}
+
+		if !log.Enabled() || !debug.Named("db:pool").Enabled() || !debug.Named("other").Enabled() {
+			t.Fatalf("expected the namespaces to be enabled by %q", debug.Config().Pattern)
+		}
+
+		if debug.Named("test:TestNamespace/n").Enabled() {
+			t.Fatalf("expected only the test namespace to be enabled by %q", debug.Config().Pattern)
+		}
+
+		log.Printf("written to the test log")
+	})
+
+	if debug.Named("test:TestNamespace/n=2,_{x}").Enabled() || debug.Named("db:pool").Enabled() {
+		t.Fatalf("expected the pattern to be restored, got %q", debug.Config().Pattern)
+	}
+
+	if c := debug.Config(); c.Pattern != "other" || c.Writer != &buf {
+		t.Fatalf("expected the configuration to be restored, got %s", c)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected output in the test log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,88 @@
+package debug
+
+// Decorator returns a prefix for the messages of namespace `name` at
+// `level`, or an empty string to leave them as is. It is called with the
+// package lock held, so it must not call this package.
+type Decorator func(name string, level Level) string
+
+// A decorator added by AddDecorator, with an identity for its removal.
+type decoratorEntry struct {
+	fn Decorator
+}
+
+var decorators []*decoratorEntry
+
+// AddDecorator adds `d` to the decorators prefixing messages written to
+// the writer, for example with a warning sign for LevelWarn and an arrow
+// for outbound requests:
+//
+//	debug.AddDecorator(debug.DecorateLevel(debug.LevelWarn, "⚠"))
+//	debug.AddDecorator(debug.DecorateNamespace("http:client:*", "→"))
+//
+// The prefixes of several decorators are joined by spaces, in the order
+// they were added. Decorations are shown only when colors are, see
+// SetColor, so that ColorAuto leaves them out of files and pipes, and
+// sinks receive records undecorated. Call the returned function to remove
+// the decorator.
+func AddDecorator(d Decorator) (remove func()) {
+	m.Lock()
+	defer m.Unlock()
+
+	e := &decoratorEntry{d}
+	decorators = append(decorators, e)
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, v := range decorators {
+			if v == e {
+				decorators = append(decorators[:i:i], decorators[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// DecorateLevel returns a Decorator prefixing messages at `level` with
+// `prefix`.
+func DecorateLevel(level Level, prefix string) Decorator {
+	return func(name string, l Level) string {
+		if l == level {
+			return prefix
+		}
+		return ""
+	}
+}
+
+// DecorateNamespace returns a Decorator prefixing messages of the
+// namespaces matching `pattern` with `prefix`.
+func DecorateNamespace(pattern, prefix string) Decorator {
+	m.Lock()
+	p := compile(pattern, false)
+	m.Unlock()
+
+	return func(name string, level Level) string {
+		if p.match(name) {
+			return prefix
+		}
+		return ""
+	}
+}
+
+// Return the message of `r` with the prefixes of the decorators. The lock
+// must be held.
+func decorate(r *Record) string {
+	var msg []byte
+	for _, d := range decorators {
+		if prefix := d.fn(r.Name, r.Level); prefix != "" {
+			msg = append(append(msg, prefix...), ' ')
+		}
+	}
+
+	if msg == nil {
+		return r.Message
+	}
+
+	return string(append(msg, r.Message...))
+}
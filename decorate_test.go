@@ -0,0 +1,32 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestDecorators(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "http:*")()
+
+	s := &recordSink{}
+	defer AddSink(s)()
+	defer AddDecorator(DecorateLevel(LevelWarn, "⚠"))()
+	defer AddDecorator(DecorateNamespace("http:client:*", "→"))()
+
+	Named("http:client").Warnf("retrying")
+	Named("http:server").Printf("listening")
+
+	str := buf.String()
+	assertContains(t, str, " - ⚠ → retrying\n")
+	assertContains(t, str, " - listening\n")
+
+	if s.records[0].Message != "retrying" {
+		t.Fatalf("expected sinks to receive undecorated records, got %q", s.records[0].Message)
+	}
+
+	buf.Reset()
+	SetColor(ColorNever)
+	defer SetColor(ColorAlways)
+
+	Named("http:client").Warnf("retrying")
+	assertContains(t, buf.String(), "WRN - retrying\n")
+}
@@ -0,0 +1,85 @@
+package debug
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Duplicate suppression state, see SetDuplicateWindow.
+var (
+	dupM      sync.Mutex
+	dupWindow time.Duration
+	dupActive atomic.Bool
+	dups      = map[dupKey]*dupEntry{}
+)
+
+// Identifies the messages of a call site.
+type dupKey struct {
+	pc      uintptr
+	n       *Namespace
+	message string
+}
+
+// When a message was last written, and how many duplicates followed.
+type dupEntry struct {
+	last  time.Time
+	count int
+}
+
+// Entries kept before expired ones are swept.
+const maxDups = 1024
+
+// SetDuplicateWindow suppresses messages identical to one written by the
+// same call site and namespace less than `d` ago, which tames duplicate
+// spam from loops and from goroutines interleaving their output. The next
+// message written after the window carries the number of suppressed
+// duplicates in a "duplicates" field. A window of zero, the default,
+// disables suppression.
+func SetDuplicateWindow(d time.Duration) {
+	dupM.Lock()
+	defer dupM.Unlock()
+	dupWindow = d
+	dupActive.Store(d > 0)
+	clear(dups)
+}
+
+// Check whether `msg` duplicates a recent message of the call site `pc`,
+// returning the number of duplicates suppressed otherwise.
+func (n *Namespace) duplicate(pc uintptr, msg string) (dup bool, count int) {
+	dupM.Lock()
+	defer dupM.Unlock()
+
+	if dupWindow <= 0 {
+		return false, 0
+	}
+
+	now := time.Now()
+	key := dupKey{pc, n, msg}
+	if e, ok := dups[key]; ok && now.Sub(e.last) < dupWindow {
+		e.count++
+		return true, 0
+	} else if ok {
+		count = e.count
+	}
+
+	if len(dups) >= maxDups {
+		for k, e := range dups {
+			if now.Sub(e.last) >= dupWindow {
+				delete(dups, k)
+			}
+		}
+	}
+
+	dups[key] = &dupEntry{last: now}
+	return false, count
+}
+
+// Return `fields` with the number of suppressed duplicates, if any.
+func withDuplicates(fields []KV, count int) []KV {
+	if count == 0 {
+		return fields
+	}
+
+	return append(fields[:len(fields):len(fields)], KV{"duplicates", count})
+}
@@ -0,0 +1,71 @@
+package debug
+
+import "bytes"
+import "runtime"
+import "strings"
+import "testing"
+import "time"
+
+func TestDuplicateWindow(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("dup")
+	SetDuplicateWindow(30 * time.Millisecond)
+	defer SetDuplicateWindow(0)
+
+	log := Named("dup")
+	for i := 0; i < 5; i++ {
+		if i == 3 {
+			time.Sleep(40 * time.Millisecond)
+		}
+		log.Printf("spam")
+		log.Printf("other %d", i)
+	}
+	log.Printf("spam")
+
+	str := buf.String()
+	if n := strings.Count(str, "spam"); n != 3 {
+		t.Fatalf("expected the spam once per window and call site, got %d in %q", n, str)
+	}
+	assertContains(t, str, "spam duplicates=2")
+	assertContains(t, str, "other 4")
+}
+
+func TestDuplicateCallSites(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "dup:sites")()
+
+	SetDuplicateWindow(time.Minute)
+	defer SetDuplicateWindow(0)
+	SetModuleFields(true)
+	defer SetModuleFields(false)
+
+	moduleM.Lock()
+	moduleCache = map[uintptr][]KV{}
+	moduleM.Unlock()
+
+	// Both functions share the namespace and message, but not the call
+	// site, so neither is a duplicate of the other.
+	Debug("dup:sites")("spam")
+	Debug("dup:sites")("spam")
+	Begin("dup:sites")("spam")
+
+	if n := strings.Count(buf.String(), "spam"); n != 3 {
+		t.Fatalf("expected each call site once, got %d in %q", n, buf.String())
+	}
+
+	// Module fields are looked up for the call sites in the test.
+	moduleM.Lock()
+	defer moduleM.Unlock()
+	for pc := range moduleCache {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if !strings.HasPrefix(frame.Function, "github.com/tj/go-debug.TestDuplicateCallSites") {
+			t.Errorf("expected the module of the call site, got %s", frame.Function)
+		}
+	}
+	if len(moduleCache) != 3 {
+		t.Errorf("expected 3 call sites, got %d", len(moduleCache))
+	}
+}
@@ -0,0 +1,45 @@
+package debug
+
+import "sort"
+
+// Description is the documentation of a namespace, see Describe.
+type Description struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// Describe documents what namespace `name` outputs, registering it if
+// needed. Descriptions are listed by Descriptions, and extracted from
+// source by the debug-scan command, so teams can publish an
+// inventory of their debug switches:
+//
+//	var log = debug.Named("db:pool")
+//
+//	func init() {
+//		debug.Describe("db:pool", "connection pool checkouts and evictions")
+//	}
+func Describe(name, doc string) {
+	n := Named(name)
+
+	m.Lock()
+	defer m.Unlock()
+	n.doc = doc
+}
+
+// Descriptions returns the descriptions of all registered namespaces,
+// sorted by name. Namespaces which were not described have an empty Doc.
+func Descriptions() []Description {
+	m.Lock()
+	defer m.Unlock()
+
+	list := make([]Description, 0, len(names))
+	for _, n := range names {
+		list = append(list, Description{n.name, n.doc})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
@@ -0,0 +1,28 @@
+package debug
+
+import "testing"
+
+func TestDescribe(t *testing.T) {
+	Named("describe:plain")
+	Describe("describe:pool", "connection pool checkouts")
+
+	var found int
+	for _, d := range Descriptions() {
+		switch d.Name {
+		case "describe:plain":
+			found++
+			if d.Doc != "" {
+				t.Errorf("unexpected doc %q", d.Doc)
+			}
+		case "describe:pool":
+			found++
+			if d.Doc != "connection pool checkouts" {
+				t.Errorf("unexpected doc %q", d.Doc)
+			}
+		}
+	}
+
+	if found != 2 {
+		t.Fatalf("expected both namespaces, found %d", found)
+	}
+}
@@ -0,0 +1,152 @@
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Diff writes the differences between `before` and `after` to namespace
+// `name`, see Namespace.Diff.
+func Diff(name string, before, after interface{}) {
+	Named(name).Diff(before, after)
+}
+
+// Diff writes one record per difference between `before` and `after` if
+// the namespace is enabled, with the path as message and the values as the
+// "before" and "after" fields, such as "spec.replicas before=2 after=3".
+// Added and removed paths only have one of the fields. Values are compared
+// by their JSON form, so struct tags are honored and unexported fields
+// ignored; values which cannot be marshalled are compared as a whole by
+// their string form. TextFormat and PrettyFormat write the values red and
+// green when colored. Nothing is written when the values are equal.
+func (n *Namespace) Diff(before, after interface{}) {
+	if !n.Enabled() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	for _, c := range diff(before, after) {
+		msg, fields := c.record()
+		n.emit(LevelDebug, msg, fields)
+	}
+}
+
+// A difference found by diff, `before` or `after` is missing when the path
+// was added or removed.
+type change struct {
+	path          string
+	before, after interface{}
+	added         bool
+	removed       bool
+}
+
+// Return the message and fields of the record of the change.
+func (c change) record() (string, []KV) {
+	switch {
+	case c.added:
+		return c.path + " added", []KV{{"after", c.after}}
+	case c.removed:
+		return c.path + " removed", []KV{{"before", c.before}}
+	default:
+		return c.path, []KV{{"before", c.before}, {"after", c.after}}
+	}
+}
+
+// Return the changes from `before` to `after`, sorted by path.
+func diff(before, after interface{}) []change {
+	a, errA := normalize(before)
+	b, errB := normalize(after)
+	if errA != nil || errB != nil {
+		a, b = fmt.Sprintf("%+v", before), fmt.Sprintf("%+v", after)
+	}
+
+	var changes []change
+	walkDiff(".", a, b, &changes)
+	return changes
+}
+
+// Return `v` decoded from its JSON form, made of maps, slices and scalars.
+func normalize(v interface{}) (interface{}, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	err = json.Unmarshal(j, &out)
+	return out, err
+}
+
+// Append the changes from `a` to `b` at `path` to `changes`.
+func walkDiff(path string, a, b interface{}, changes *[]change) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffMaps(path, a, b, changes)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffSlices(path, a, b, changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, change{path: path, before: a, after: b})
+	}
+}
+
+// Append the changes between the maps `a` and `b`.
+func diffMaps(path string, a, b map[string]interface{}, changes *[]change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := k
+		if path != "." {
+			p = path + "." + k
+		}
+
+		va, okA := a[k]
+		vb, okB := b[k]
+		switch {
+		case !okA:
+			*changes = append(*changes, change{path: p, after: vb, added: true})
+		case !okB:
+			*changes = append(*changes, change{path: p, before: va, removed: true})
+		default:
+			walkDiff(p, va, vb, changes)
+		}
+	}
+}
+
+// Append the changes between the slices `a` and `b`, compared by index.
+func diffSlices(path string, a, b []interface{}, changes *[]change) {
+	if path == "." {
+		path = ""
+	}
+
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, change{path: p, after: b[i], added: true})
+		case i >= len(b):
+			*changes = append(*changes, change{path: p, before: a[i], removed: true})
+		default:
+			walkDiff(p, a[i], b[i], changes)
+		}
+	}
+}
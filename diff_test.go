@@ -0,0 +1,59 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestDiff(t *testing.T) {
+	type spec struct {
+		Replicas int               `json:"replicas"`
+		Labels   map[string]string `json:"labels"`
+		Ports    []int             `json:"ports"`
+	}
+
+	before := spec{Replicas: 2, Labels: map[string]string{"tier": "web"}, Ports: []int{80, 443}}
+	after := spec{Replicas: 3, Labels: map[string]string{"app": "shop", "tier": "web"}, Ports: []int{80}}
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "")()
+
+	Diff("config:reload", before, after)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output when disabled, got %q", buf.String())
+	}
+
+	Enable("config:*")
+	SetColor(ColorNever)
+	defer SetColor(ColorAlways)
+
+	Diff("config:reload", before, after)
+	assertContains(t, buf.String(), "labels.app added after=shop")
+	assertContains(t, buf.String(), "ports[1] removed before=443")
+	assertContains(t, buf.String(), "replicas before=2 after=3")
+	assertNotContains(t, buf.String(), "tier")
+
+	buf.Reset()
+	Diff("config:reload", before, before)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output for equal values, got %q", buf.String())
+	}
+
+	Diff("config:reload", []int{1}, "1")
+	assertContains(t, buf.String(), ". before=[1] after=1")
+}
+
+func TestDiffColors(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "config:*")()
+
+	s := &recordSink{}
+	remove := AddSink(s)
+	defer remove()
+
+	Diff("config:reload", map[string]int{"replicas": 2}, map[string]int{"replicas": 3})
+	assertContains(t, buf.String(), "replicas before=\033[31m2\033[0m after=\033[32m3\033[0m")
+
+	if len(s.records) != 1 || s.records[0].Message != "replicas" {
+		t.Fatalf("unexpected records %v", s.records)
+	}
+}
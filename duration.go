@@ -0,0 +1,48 @@
+package debug
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Current duration format, see SetDurationFormat. It is read without the
+// lock by formatters.
+var durationFormat atomic.Pointer[DurationFormat]
+
+// DurationFormat appends a duration to `b`, for the time columns of the
+// human readable formats.
+type DurationFormat func(b []byte, d time.Duration) []byte
+
+// HumanDuration is the default duration format, in whole nanoseconds,
+// microseconds, milliseconds or seconds, such as "12ms".
+func HumanDuration(b []byte, d time.Duration) []byte {
+	return appendHumanizeNano(b, d.Nanoseconds())
+}
+
+// Milliseconds returns a duration format always in milliseconds with
+// `decimals` fixed decimals, such as "12.345ms" with 3 decimals, so that
+// log parsers need not handle several unit suffixes.
+func Milliseconds(decimals int) DurationFormat {
+	return func(b []byte, d time.Duration) []byte {
+		b = strconv.AppendFloat(b, float64(d)/float64(time.Millisecond), 'f', decimals, 64)
+		return append(b, "ms"...)
+	}
+}
+
+// SetDurationFormat replaces the default of HumanDuration with `f`. A nil
+// format restores the default.
+func SetDurationFormat(f DurationFormat) {
+	if f == nil {
+		f = HumanDuration
+	}
+	durationFormat.Store(&f)
+}
+
+// Append `d` in the current duration format.
+func appendDuration(b []byte, d time.Duration) []byte {
+	if f := durationFormat.Load(); f != nil {
+		return (*f)(b, d)
+	}
+	return HumanDuration(b, d)
+}
@@ -0,0 +1,80 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// EnableEnv adds the pattern of the environment variable `key` to the
+// current one, layered like DEBUG with <key>_EXTRA and <key>_<BINARY>, so
+// that an application or library can be configured with a variable of its
+// own, such as MYAPP_DEBUG, rather than competing for DEBUG:
+//
+//	func init() {
+//		debug.EnableEnv("MYAPP_DEBUG")
+//	}
+//
+// Use Flag to expose patterns as a flag of any name.
+//
+// This function is thread-safe.
+func EnableEnv(key string) {
+	p, help := stripHelp(envPattern(os.Getenv, key, binaryName()))
+	if help {
+		printStartupHelp()
+	}
+
+	if p == "" {
+		return
+	}
+
+	m.Lock()
+	defer unlock()
+
+	if current != "" {
+		p = current + "," + p
+	}
+
+	next := compile(p, ordered)
+	if prev := active.Load(); prev != nil {
+		next.custom = prev.custom
+	}
+
+	setActive(next)
+	current = p
+	generation++
+}
+
+// Return the pattern layered from the environment variable `key`, such as
+// DEBUG, typically set by base images, then <key>_EXTRA, then <key>_<NAME>
+// for the binary, such as DEBUG_API_SERVER for "api-server". Later layers
+// add to earlier ones, and win for EnableOrdered patterns.
+func envPattern(getenv func(string) string, key, binary string) string {
+	var layers []string
+	for _, k := range []string{key, key + "_EXTRA", key + "_" + envName(binary)} {
+		if v := strings.TrimSpace(getenv(k)); v != "" {
+			layers = append(layers, v)
+		}
+	}
+	return strings.Join(layers, ",")
+}
+
+// Return `binary` in the form of an environment variable name.
+func envName(binary string) string {
+	name := strings.TrimSuffix(filepath.Base(binary), ".exe")
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
+
+// Return the name of the running binary.
+func binaryName() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return os.Args[0]
+}
@@ -0,0 +1,53 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestEnvPattern(t *testing.T) {
+	env := map[string]string{
+		"DEBUG":            "db:*",
+		"DEBUG_EXTRA":      " http ",
+		"DEBUG_API_SERVER": "-db:pool",
+		"DEBUG_OTHER":      "other",
+	}
+
+	getenv := func(key string) string {
+		return env[key]
+	}
+
+	if p := envPattern(getenv, "DEBUG", "/usr/bin/api-server"); p != "db:*,http,-db:pool" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+
+	if p := envPattern(getenv, "DEBUG", "/opt/api.server.exe"); p != "db:*,http,-db:pool" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+
+	delete(env, "DEBUG")
+	if p := envPattern(getenv, "DEBUG", "other"); p != "http,other" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+}
+
+func TestEnableEnv(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "db:*")()
+
+	t.Setenv("MYAPP_DEBUG", "http")
+	t.Setenv("MYAPP_DEBUG_EXTRA", "-db:pool")
+	EnableEnv("MYAPP_DEBUG")
+
+	if p := Config().Pattern; p != "db:*,http,-db:pool" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+
+	Debug("http")("request")
+	Debug("db:pool")("checkout")
+	assertContains(t, buf.String(), "request")
+	assertNotContains(t, buf.String(), "checkout")
+
+	EnableEnv("MYAPP_UNSET")
+	if p := Config().Pattern; p != "db:*,http,-db:pool" {
+		t.Fatalf("unexpected pattern %q", p)
+	}
+}
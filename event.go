@@ -0,0 +1,27 @@
+package debug
+
+// Event writes a canonical event record of type `eventType` to namespace
+// `name`, for teams treating debug output as an event stream for tooling.
+// Unlike printf-style lines, events have the type as their message and a
+// first "event" field, followed by `fields`, so structured formats and
+// sinks can index them without parsing, and the fingerprint is that of
+// the type:
+//
+//	debug.Event("cache", "evicted", debug.KV{Key: "key", Value: k}, debug.KV{Key: "age", Value: age})
+func Event(name, eventType string, fields ...KV) {
+	Named(name).Event(eventType, fields...)
+}
+
+// Event writes an event record of type `eventType` if the namespace is
+// enabled, see Event.
+func (n *Namespace) Event(eventType string, fields ...KV) {
+	if !n.Enabled() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	kvs := make([]KV, 0, len(fields)+1)
+	kvs = append(kvs, KV{"event", eventType})
+	kvs = append(kvs, fields...)
+	n.emitFormat(LevelDebug, eventType, eventType, kvs)
+}
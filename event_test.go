@@ -0,0 +1,31 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestEvent(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "cache")()
+
+	sink := &recordSink{}
+	defer AddSink(sink)()
+
+	Event("cache", "evicted", KV{"key", "user:1"}, KV{"age", 3})
+	Event("other", "hidden")
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected one record, got %d", len(sink.records))
+	}
+
+	r := sink.records[0]
+	if r.Message != "evicted" || len(r.Fields) != 3 || r.Fields[0] != (KV{"event", "evicted"}) || r.Fields[2] != (KV{"age", 3}) {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	if r.Fingerprint != fingerprint("cache", "evicted") {
+		t.Fatalf("expected the fingerprint of the event type")
+	}
+
+	assertContains(t, buf.String(), " - evicted event=evicted key=user:1 age=3")
+}
@@ -0,0 +1,146 @@
+package debug
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// Event log functions of advapi32.
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+)
+
+// Event types.
+const (
+	eventError       = 0x0001
+	eventWarning     = 0x0002
+	eventInformation = 0x0004
+)
+
+// EventLogSink is a Sink writing records to the Windows Event Log. Errors
+// and warnings are reported as such, other levels as information events.
+type EventLogSink struct {
+	source  string
+	handles map[string]syscall.Handle
+	buf     []byte
+}
+
+// NewEventLogSink returns a sink reporting events from `source`, with the
+// namespace prefixed to each message. When `source` is empty each
+// namespace reports as its own event source instead, so that the Event
+// Viewer can filter on it. Add it with AddSink.
+//
+// Sources which were not registered, for example by an installer, still
+// work, however the Event Viewer then notes that their description is
+// missing before the message.
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	s := &EventLogSink{
+		source:  source,
+		handles: map[string]syscall.Handle{},
+	}
+
+	if source != "" {
+		if _, err := s.handle(source); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Write implements Sink.
+func (s *EventLogSink) Write(r *Record) error {
+	source := s.source
+	if source == "" {
+		source = r.Name
+	}
+
+	h, err := s.handle(source)
+	if err != nil {
+		return err
+	}
+
+	s.buf = appendEventMessage(s.buf[:0], r, s.source != "")
+	msg, err := syscall.UTF16PtrFromString(string(s.buf))
+	if err != nil {
+		return err
+	}
+
+	strs := [1]*uint16{msg}
+	ok, _, err := procReportEvent.Call(
+		uintptr(h),
+		uintptr(eventType(r.Level)),
+		0, // category
+		1, // event id
+		0, // user sid
+		1, // number of strings
+		0, // size of binary data
+		uintptr(unsafe.Pointer(&strs[0])),
+		0, // binary data
+	)
+	if ok == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// Close deregisters the event sources.
+func (s *EventLogSink) Close() error {
+	var err error
+	for source, h := range s.handles {
+		if ok, _, e := procDeregisterEventSource.Call(uintptr(h)); ok == 0 && err == nil {
+			err = e
+		}
+		delete(s.handles, source)
+	}
+	return err
+}
+
+// Return the handle of event source `source`, registering it on first use.
+func (s *EventLogSink) handle(source string) (syscall.Handle, error) {
+	if h, ok := s.handles[source]; ok {
+		return h, nil
+	}
+
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return 0, err
+	}
+
+	h, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(name)))
+	if h == 0 {
+		return 0, err
+	}
+
+	s.handles[source] = syscall.Handle(h)
+	return syscall.Handle(h), nil
+}
+
+// Return the event type of `level`.
+func eventType(level Level) uint16 {
+	switch level {
+	case LevelError:
+		return eventError
+	case LevelWarn:
+		return eventWarning
+	default:
+		return eventInformation
+	}
+}
+
+// Append the message of `r`, prefixed by its namespace if `named`. NUL
+// characters, which would end the message, are replaced.
+func appendEventMessage(b []byte, r *Record, named bool) []byte {
+	if named {
+		b = append(b, r.Name...)
+		b = append(b, " - "...)
+	}
+
+	b = append(b, strings.ReplaceAll(r.Message, "\x00", "\\0")...)
+	return appendFields(b, r.Fields)
+}
@@ -0,0 +1,19 @@
+package debug
+
+import "testing"
+
+func TestEventMessage(t *testing.T) {
+	r := &Record{Name: "svc:db", Message: "lost\x00connection", Fields: []KV{{"retry", 2}}}
+
+	if s := string(appendEventMessage(nil, r, true)); s != `svc:db - lost\0connection retry=2` {
+		t.Fatalf("unexpected message %q", s)
+	}
+
+	if s := string(appendEventMessage(nil, r, false)); s != `lost\0connection retry=2` {
+		t.Fatalf("unexpected message %q", s)
+	}
+
+	if eventType(LevelWarn) != eventWarning || eventType(LevelDebug) != eventInformation {
+		t.Fatalf("unexpected event types")
+	}
+}
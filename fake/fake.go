@@ -0,0 +1,93 @@
+// Package fake provides a debug.Debugger which records calls in memory,
+// so unit tests can assert on what was logged without matching strings.
+package fake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tj/go-debug"
+)
+
+// Call is a single recorded invocation of a debug function.
+type Call struct {
+	Name   string
+	Format string
+	Args   []interface{}
+	Time   time.Time
+}
+
+// Message returns the formatted message of the call.
+func (c Call) Message() string {
+	return fmt.Sprintf(c.Format, c.Args...)
+}
+
+// Debugger records every call made through the debug functions it hands
+// out, regardless of the DEBUG pattern. The zero value is ready to use.
+type Debugger struct {
+	m     sync.Mutex
+	calls []Call
+}
+
+// New returns an empty Debugger.
+func New() *Debugger {
+	return &Debugger{}
+}
+
+// Debug implements debug.Debugger.
+func (d *Debugger) Debug(name string) debug.DebugFunction {
+	return func(format string, args ...interface{}) {
+		d.m.Lock()
+		defer d.m.Unlock()
+		d.calls = append(d.calls, Call{
+			Name:   name,
+			Format: format,
+			Args:   args,
+			Time:   time.Now(),
+		})
+	}
+}
+
+// Calls returns a copy of all recorded calls in order.
+func (d *Debugger) Calls() []Call {
+	return d.Find(func(Call) bool { return true })
+}
+
+// Named returns the calls made for namespace `name`.
+func (d *Debugger) Named(name string) []Call {
+	return d.Find(func(c Call) bool { return c.Name == name })
+}
+
+// Find returns the calls for which `match` returns true.
+func (d *Debugger) Find(match func(Call) bool) []Call {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	var calls []Call
+	for _, c := range d.calls {
+		if match(c) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Count returns the number of calls made for namespace `name`.
+func (d *Debugger) Count(name string) int {
+	return len(d.Named(name))
+}
+
+// Len returns the total number of recorded calls.
+func (d *Debugger) Len() int {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return len(d.calls)
+}
+
+// Reset discards all recorded calls.
+func (d *Debugger) Reset() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.calls = nil
+}
@@ -0,0 +1,51 @@
+package fake
+
+import (
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+var _ debug.Debugger = New()
+
+func TestRecord(t *testing.T) {
+	d := New()
+
+	retry := d.Debug("client:retry")
+	conn := d.Debug("client:conn")
+
+	conn("dialing %s", "localhost")
+	retry("attempt %d failed", 1)
+
+	if d.Len() != 2 {
+		t.Fatalf("expected 2 calls, got %d", d.Len())
+	}
+
+	if d.Count("client:retry") != 1 {
+		t.Fatalf("expected exactly one retry call")
+	}
+
+	c := d.Named("client:retry")[0]
+	if c.Format != "attempt %d failed" || c.Args[0] != 1 {
+		t.Fatalf("unexpected call %+v", c)
+	}
+
+	if c.Message() != "attempt 1 failed" {
+		t.Fatalf("unexpected message %q", c.Message())
+	}
+
+	if c.Time.IsZero() {
+		t.Fatalf("expected call time to be set")
+	}
+}
+
+func TestReset(t *testing.T) {
+	var d Debugger
+
+	d.Debug("foo")("bar")
+	d.Reset()
+
+	if d.Len() != 0 {
+		t.Fatalf("expected no calls after reset")
+	}
+}
@@ -0,0 +1,59 @@
+package debug
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Pool of message buffers for Append.
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// Bytes writes the pre-formatted `msg` if the namespace is enabled. Unlike
+// Printf it avoids fmt entirely, and does not allocate with the text
+// format and no fields, making it suitable for very hot paths. `msg` is
+// not retained.
+func (n *Namespace) Bytes(msg []byte) {
+	if !n.Enabled() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	n.emit(LevelDebug, bytesToString(msg), nil)
+}
+
+// Append writes the message appended to a pooled buffer by `fn`, which is
+// only called while the namespace is enabled. Like Bytes it does not
+// allocate, as long as `fn` does not, for example:
+//
+//	log.Append(func(b []byte) []byte {
+//		b = append(b, "read "...)
+//		b = strconv.AppendInt(b, int64(n), 10)
+//		return append(b, " bytes"...)
+//	})
+func (n *Namespace) Append(fn func(b []byte) []byte) {
+	if !n.Enabled() {
+		n.stats.suppressed.Add(1)
+		return
+	}
+
+	bp := bufPool.Get().(*[]byte)
+	b := fn((*bp)[:0])
+	n.emit(LevelDebug, bytesToString(b), nil)
+	*bp = b
+	bufPool.Put(bp)
+}
+
+// Return a string sharing the memory of `b`. The string is only valid
+// while `b` is unchanged, which holds for the duration of emit as records
+// are not retained.
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(&b[0], len(b))
+}
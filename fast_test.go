@@ -0,0 +1,71 @@
+package debug
+
+import "bytes"
+import "io"
+import "strconv"
+import "testing"
+
+func TestAppend(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("fast")
+
+	log := Named("fast")
+	log.Bytes([]byte("pre-formatted"))
+	log.Append(func(b []byte) []byte {
+		return strconv.AppendInt(append(b, "n="...), 42, 10)
+	})
+
+	str := buf.String()
+	assertContains(t, str, "fast\033[0m - pre-formatted\n")
+	assertContains(t, str, "fast\033[0m - n=42\n")
+}
+
+func TestAppendAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are counted by the race detector")
+	}
+
+	SetWriter(io.Discard)
+	Enable("fast")
+
+	log := Named("fast")
+	n := int64(0)
+	msg := []byte("static")
+	allocs := testing.AllocsPerRun(100, func() {
+		log.Append(func(b []byte) []byte {
+			return strconv.AppendInt(append(b, "n="...), n, 10)
+		})
+		log.Bytes(msg)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkPrintf(b *testing.B) {
+	SetWriter(io.Discard)
+	Enable("bench")
+	log := Named("bench")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.Printf("read %d bytes", i)
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	SetWriter(io.Discard)
+	Enable("bench")
+	log := Named("bench")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		log.Append(func(b []byte) []byte {
+			b = append(b, "read "...)
+			b = strconv.AppendInt(b, int64(i), 10)
+			return append(b, " bytes"...)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package debug
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Files kept open by SetFilePattern.
+const maxPatternFiles = 64
+
+// SetFilePattern replaces the writer with one file per top-level
+// namespace, named by replacing "{namespace}" in `pattern`, so subsystems
+// can be tailed independently:
+//
+//	debug.SetFilePattern("/var/log/app/{namespace}.log")
+//
+// writes "db:pool" and "db:conn" to db.log and "http" to http.log. Files are
+// appended to, and their directories created as needed. At most 64 files
+// are kept open, closing the least recently used, and replacing the writer
+// closes them all. Colors are not written.
+func SetFilePattern(pattern string) error {
+	if !strings.Contains(pattern, "{namespace}") {
+		return errors.New("debug: file pattern without {namespace}")
+	}
+
+	SetWriter(&fileRouter{
+		pattern: pattern,
+		files:   map[string]*list.Element{},
+		lru:     list.New(),
+	})
+	return nil
+}
+
+// A writer of records to the files of a pattern. It is only used with
+// the package lock held.
+type fileRouter struct {
+	pattern string
+	files   map[string]*list.Element
+	lru     *list.List
+	buf     []byte
+}
+
+// An open file of a top-level namespace.
+type routedFile struct {
+	name string
+	file *os.File
+}
+
+// Write implements io.Writer, failing as the output of a file pattern
+// depends on the namespace.
+func (f *fileRouter) Write(b []byte) (int, error) {
+	return 0, errors.New("debug: file pattern writer only accepts records")
+}
+
+// Flush commits the open files to stable storage, as records are written
+// to them unbuffered. The files stay open, see Close.
+func (f *fileRouter) Flush() error {
+	var err error
+	for e := f.lru.Front(); e != nil; e = e.Next() {
+		if serr := e.Value.(*routedFile).file.Sync(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// Close closes the open files, which are reopened on the next record.
+func (f *fileRouter) Close() error {
+	var err error
+	for f.lru.Len() > 0 {
+		if e := f.closeOldest(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Write `r` to the file of its top-level namespace.
+func (f *fileRouter) writeRecord(r *Record, color string) {
+	file, err := f.open(topLevel(r.Name))
+	if err != nil {
+		return
+	}
+
+	f.buf = formatter.Format(f.buf[:0], r, "")
+	file.Write(f.buf)
+}
+
+// Return the file of top-level namespace `name`, opening it if needed.
+func (f *fileRouter) open(name string) (*os.File, error) {
+	if e, ok := f.files[name]; ok {
+		f.lru.MoveToFront(e)
+		return e.Value.(*routedFile).file, nil
+	}
+
+	path := strings.ReplaceAll(f.pattern, "{namespace}", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.lru.Len() >= maxPatternFiles {
+		f.closeOldest()
+	}
+
+	f.files[name] = f.lru.PushFront(&routedFile{name, file})
+	return file, nil
+}
+
+// Close the least recently used file.
+func (f *fileRouter) closeOldest() error {
+	e := f.lru.Back()
+	rf := f.lru.Remove(e).(*routedFile)
+	delete(f.files, rf.name)
+	return rf.file.Close()
+}
+
+// Return the top-level segment of `name`, made safe for use in a path.
+func topLevel(name string) string {
+	if i := strings.Index(name, Separator()); i > 0 {
+		name = name[:i]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+
+	return name
+}
@@ -0,0 +1,62 @@
+package debug
+
+import "container/list"
+import "os"
+import "path/filepath"
+import "testing"
+
+func TestFilePattern(t *testing.T) {
+	dir := t.TempDir()
+	prev := SetWriter(os.Stderr)
+	defer SetWriter(prev)
+
+	if err := SetFilePattern(filepath.Join(dir, "app.log")); err == nil {
+		t.Fatalf("expected an error without {namespace}")
+	}
+
+	if err := SetFilePattern(filepath.Join(dir, "logs", "{namespace}.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	Enable("fp*,/etc")
+	Debug("fpdb:pool")("checkout")
+	Debug("fpdb:conn")("connect")
+	Debug("fphttp")("request")
+	Debug("/etc")("escaped")
+
+	// Restoring a writer closes the files.
+	SetWriter(os.Stderr)
+
+	db, _ := os.ReadFile(filepath.Join(dir, "logs", "fpdb.log"))
+	assertContains(t, string(db), "fpdb:pool - checkout")
+	assertContains(t, string(db), "fpdb:conn - connect")
+	assertNotContains(t, string(db), "\033[")
+
+	http, _ := os.ReadFile(filepath.Join(dir, "logs", "fphttp.log"))
+	assertContains(t, string(http), "fphttp - request")
+
+	etc, _ := os.ReadFile(filepath.Join(dir, "logs", "_etc.log"))
+	assertContains(t, string(etc), "escaped")
+}
+
+func TestFilePatternLRU(t *testing.T) {
+	dir := t.TempDir()
+	f := &fileRouter{pattern: filepath.Join(dir, "{namespace}.log"), files: map[string]*list.Element{}, lru: list.New()}
+
+	for i := 0; i < maxPatternFiles+10; i++ {
+		f.writeRecord(&Record{Name: string(rune('a'+i%26)) + string(rune('a'+i/26))}, "")
+	}
+
+	if f.lru.Len() != maxPatternFiles || len(f.files) != maxPatternFiles {
+		t.Fatalf("expected %d open files, got %d", maxPatternFiles, f.lru.Len())
+	}
+
+	if err := f.Flush(); err != nil || f.lru.Len() != maxPatternFiles {
+		t.Fatalf("expected the files to be synced and kept open, got %v", err)
+	}
+
+	f.Close()
+	if f.lru.Len() != 0 {
+		t.Fatalf("expected the files to be closed")
+	}
+}
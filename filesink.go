@@ -0,0 +1,169 @@
+package debug
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileOptions configures a FileSink.
+type FileOptions struct {
+	// Format of the records, TextFormat without colors when nil.
+	Format Formatter
+
+	// Compress, when set, wraps the file with a compressing writer, such
+	// as Gzip. Writers with a Flush method, like gzip and zstd encoders,
+	// are flushed periodically so that the file can be read while it is
+	// written, and after a crash.
+	Compress func(w io.Writer) io.WriteCloser
+
+	// FlushInterval between flushes, one second when zero.
+	FlushInterval time.Duration
+}
+
+// Gzip compresses a FileSink with gzip. Other algorithms plug in the same
+// way, for example zstd with:
+//
+//	func(w io.Writer) io.WriteCloser {
+//		enc, _ := zstd.NewWriter(w)
+//		return enc
+//	}
+func Gzip(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+// FileSink is a Sink writing records to a file, optionally compressed,
+// since verbose debug files routinely reach gigabytes.
+type FileSink struct {
+	format Formatter
+
+	m    sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+	w    io.Writer
+	zw   io.WriteCloser
+	out  []byte
+	err  error
+	done chan struct{}
+}
+
+// OpenFile creates or truncates the file at `path` and returns a FileSink
+// writing to it. Add it with AddSink, and Close it when done.
+func OpenFile(path string, opts FileOptions) (*FileSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Format == nil {
+		opts.Format = TextFormat
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+
+	s := &FileSink{
+		format: opts.Format,
+		file:   f,
+		buf:    bufio.NewWriterSize(f, 64<<10),
+		done:   make(chan struct{}),
+	}
+
+	s.w = s.buf
+	if opts.Compress != nil {
+		s.zw = opts.Compress(s.buf)
+		s.w = s.zw
+	}
+
+	go s.flushEvery(opts.FlushInterval)
+	return s, nil
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(r *Record) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.out = s.format.Format(s.out[:0], r, "")
+	_, s.err = s.w.Write(s.out)
+	return s.err
+}
+
+// Flush writes the buffered records to the file, completing a flush point
+// of the compressed stream.
+func (s *FileSink) Flush() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.flush()
+}
+
+// Close flushes the remaining records, ends the compressed stream and
+// closes the file.
+func (s *FileSink) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	close(s.done)
+
+	err := s.err
+	if s.zw != nil {
+		if e := s.zw.Close(); err == nil {
+			err = e
+		}
+	}
+
+	if e := s.buf.Flush(); err == nil {
+		err = e
+	}
+
+	if e := s.file.Close(); err == nil {
+		err = e
+	}
+
+	s.file = nil
+	s.err = os.ErrClosed
+	return err
+}
+
+// Flush the compressor and the buffer, the lock must be held.
+func (s *FileSink) flush() error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if f, ok := s.zw.(flusher); ok {
+		if s.err = f.Flush(); s.err != nil {
+			return s.err
+		}
+	}
+
+	s.err = s.buf.Flush()
+	return s.err
+}
+
+// Flush every `interval` until closed.
+func (s *FileSink) flushEvery(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+			s.Flush()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package debug
+
+import "compress/gzip"
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+import "time"
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+
+	s, err := OpenFile(path, FileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Write(&Record{Time: time.Now(), Name: "file", Message: "plain"})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(path)
+	assertContains(t, string(b), "file - plain\n")
+
+	if err := s.Write(&Record{}); err == nil {
+		t.Fatalf("expected an error after Close")
+	}
+}
+
+func TestFileSinkGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl.gz")
+
+	s, err := OpenFile(path, FileOptions{
+		Format:        JSONFormat,
+		Compress:      Gzip,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.Write(&Record{Time: time.Now(), Name: "file", Message: strings.Repeat("compressible ", 100)})
+
+	// A flush point is written periodically, making the records readable
+	// before the stream is closed.
+	time.Sleep(50 * time.Millisecond)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rd, err := NewReader(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := rd.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Name != "file" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+}
@@ -0,0 +1,36 @@
+package debug
+
+import "flag"
+
+// Flag registers a flag `name` on `fs`, or flag.CommandLine when nil,
+// whose value is passed to Enable, so CLI tools can expose patterns as a
+// flag such as --debug="mongo:*" instead of requiring the DEBUG
+// environment variable.
+func Flag(fs *flag.FlagSet, name string) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	fs.Var(&PatternValue{}, name, "enable debug output for the comma-separated namespace `pattern`")
+}
+
+// PatternValue is a flag value applying patterns with Enable. It also
+// implements the Type method of spf13/pflag values, so it can be used
+// with pflag and cobra.
+type PatternValue struct{}
+
+// String returns the current pattern.
+func (*PatternValue) String() string {
+	return Config().Pattern
+}
+
+// Set enables `pattern`.
+func (*PatternValue) Set(pattern string) error {
+	Enable(pattern)
+	return nil
+}
+
+// Type returns the type name shown in pflag usage.
+func (*PatternValue) Type() string {
+	return "pattern"
+}
@@ -0,0 +1,26 @@
+package debug
+
+import "bytes"
+import "flag"
+import "testing"
+
+func TestFlag(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	Disable()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	Flag(fs, "debug")
+
+	if err := fs.Parse([]string{"--debug=flag:*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	Debug("flag:test")("enabled by flag")
+	assertContains(t, buf.String(), "enabled by flag")
+
+	if v := fs.Lookup("debug").Value.String(); v != "flag:*" {
+		t.Fatalf("unexpected flag value %q", v)
+	}
+}
@@ -0,0 +1,382 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Formatter renders a Record as output, appending it to `b`. The `color`
+// is the ANSI color code of the namespace, or empty when colors are off.
+// Formatters must be safe for concurrent use, as sinks such as an
+// AsyncSink call them without the package lock.
+type Formatter interface {
+	Format(b []byte, r *Record, color string) []byte
+}
+
+// Built-in formatters.
+var (
+	// TextFormat is the default human readable format. Names are
+	// abbreviated to the width given to SetNameWidth, and records above
+	// LevelDebug are marked with a colored badge such as "WRN".
+	TextFormat Formatter = textFormat{}
+
+	// JSONFormat writes one JSON object per line, with fields inlined
+	// after the time, name, namespace id, level, message, deltas,
+	// elapsed time and fingerprint. The level is omitted for LevelDebug,
+	// and the elapsed time and fingerprint when zero. The fingerprint is
+	// written as a hexadecimal string.
+	JSONFormat Formatter = jsonFormat{}
+
+	// PrettyFormat is a richer human readable format for structured
+	// output. Names are padded to the longest one written so far so that
+	// messages line up, every record has a level badge, errors are red,
+	// and fields are rendered as dimmed key=value pairs.
+	PrettyFormat Formatter = &prettyFormat{}
+)
+
+type textFormat struct{}
+
+// Format implements Formatter.
+func (textFormat) Format(b []byte, r *Record, color string) []byte {
+	b = appendTime(b, r)
+	b = append(b, ' ')
+	b = appendPadded(b, r.Global, 6)
+	b = append(b, ' ')
+	b = appendColor(b, color)
+	b = appendPadded(b, r.Delta, 6)
+	b = append(b, ' ')
+	b = appendColor(b, color)
+	b = append(b, abbreviate(r.Name, int(nameWidth.Load()), Separator())...)
+	b = appendReset(b, color)
+
+	if r.Level != LevelDebug {
+		b = append(b, ' ')
+		if color != "" {
+			b = appendColor(b, r.Level.Color())
+		}
+		b = append(b, r.Level.Badge()...)
+		b = appendReset(b, color)
+	}
+
+	b = append(b, " - "...)
+	b = append(b, r.Message...)
+
+	if color == "" {
+		b = appendFields(b, r.Fields)
+	} else {
+		for _, f := range r.Fields {
+			b = append(b, ' ')
+			b = append(b, f.Key...)
+			b = append(b, '=')
+			c := changeColor(f.Key)
+			b = appendColor(b, c)
+			b = fmt.Append(b, f.Value)
+			b = appendReset(b, c)
+		}
+	}
+	return append(b, '\n')
+}
+
+// Append the duration `d` in the current format, padded to `width`.
+func appendPadded(b []byte, d time.Duration, width int) []byte {
+	start := len(b)
+	b = appendDuration(b, d)
+	for n := bytesWidth(b[start:]); n < width; n++ {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// Append the escape sequence of `color`, if any.
+func appendColor(b []byte, color string) []byte {
+	if color == "" {
+		return b
+	}
+	b = append(b, "\033["...)
+	b = append(b, color...)
+	return append(b, 'm')
+}
+
+// Append the escape sequence resetting colors, if `color` is set.
+func appendReset(b []byte, color string) []byte {
+	if color == "" {
+		return b
+	}
+	return append(b, "\033[0m"...)
+}
+
+// Return the color of the values of field `key` when colored, red and green
+// for the "before" and "after" fields of changes, see Namespace.Diff.
+func changeColor(key string) string {
+	switch key {
+	case "before":
+		return "31"
+	case "after":
+		return "32"
+	default:
+		return ""
+	}
+}
+
+// Append `fields` as space separated key=value pairs.
+func appendFields(b []byte, fields []KV) []byte {
+	for _, f := range fields {
+		b = fmt.Appendf(b, " %s=%v", f.Key, f.Value)
+	}
+	return b
+}
+
+type prettyFormat struct {
+	width atomic.Int64
+}
+
+// Format implements Formatter. The width is atomic, as sinks such as an
+// AsyncSink format records without the package lock.
+func (p *prettyFormat) Format(b []byte, r *Record, color string) []byte {
+	b = appendColor(b, dim(color))
+	b = appendTime(b, r)
+	b = append(b, " +"...)
+	b = appendPadded(b, r.Delta, 6)
+	b = appendReset(b, color)
+	b = append(b, ' ')
+
+	name := abbreviate(r.Name, int(nameWidth.Load()), Separator())
+	width := int64(DisplayWidth(name))
+	for w := p.width.Load(); width > w; w = p.width.Load() {
+		if p.width.CompareAndSwap(w, width) {
+			break
+		}
+	}
+
+	b = appendColor(b, color)
+	b = append(b, name...)
+	b = appendReset(b, color)
+	for n, w := width, p.width.Load(); n < w; n++ {
+		b = append(b, ' ')
+	}
+
+	b = append(b, ' ')
+	if color != "" {
+		b = appendColor(b, r.Level.Color())
+	}
+	b = append(b, r.Level.Badge()...)
+	b = appendReset(b, color)
+	b = append(b, ' ')
+
+	if r.Level == LevelError && color != "" {
+		b = appendColor(b, LevelError.Color())
+		b = append(b, r.Message...)
+		b = appendReset(b, color)
+	} else {
+		b = append(b, r.Message...)
+	}
+
+	for _, f := range r.Fields {
+		b = append(b, ' ')
+		b = appendColor(b, dim(color))
+		b = append(b, f.Key...)
+		b = append(b, '=')
+		b = appendReset(b, color)
+
+		if err, ok := f.Value.(error); ok {
+			if color != "" {
+				b = appendColor(b, LevelError.Color())
+			}
+			b = appendValue(b, err.Error())
+			b = appendReset(b, color)
+			continue
+		}
+
+		c := ""
+		if color != "" {
+			c = changeColor(f.Key)
+		}
+		b = appendColor(b, c)
+		b = appendValue(b, fmt.Sprint(f.Value))
+		b = appendReset(b, c)
+	}
+
+	return append(b, '\n')
+}
+
+// Return the dim style when colors are on.
+func dim(color string) string {
+	if color == "" {
+		return ""
+	}
+	return "2"
+}
+
+// Append `s`, quoted when it is empty or contains spaces or quotes.
+func appendValue(b []byte, s string) []byte {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.AppendQuote(b, s)
+	}
+	return append(b, s...)
+}
+
+type jsonFormat struct{}
+
+// Format implements Formatter.
+func (jsonFormat) Format(b []byte, r *Record, color string) []byte {
+	b = append(b, `{"time":`...)
+	b = strconv.AppendQuote(b, r.Time.UTC().Format(time.RFC3339Nano))
+	if r.Name != "" || r.ID == 0 {
+		b = append(b, `,"name":`...)
+		b = appendJSON(b, r.Name)
+	}
+	if r.ID != 0 {
+		b = append(b, `,"id":`...)
+		b = strconv.AppendUint(b, uint64(r.ID), 10)
+	}
+	if r.Level != LevelDebug {
+		b = append(b, `,"level":`...)
+		b = strconv.AppendQuote(b, r.Level.String())
+	}
+	b = append(b, `,"message":`...)
+	b = appendJSON(b, r.Message)
+	b = append(b, `,"global":`...)
+	b = strconv.AppendInt(b, r.Global.Nanoseconds(), 10)
+	b = append(b, `,"delta":`...)
+	b = strconv.AppendInt(b, r.Delta.Nanoseconds(), 10)
+	if r.Elapsed != 0 {
+		b = append(b, `,"elapsed":`...)
+		b = strconv.AppendInt(b, r.Elapsed.Nanoseconds(), 10)
+	}
+	if r.Fingerprint != 0 {
+		b = append(b, `,"fingerprint":"`...)
+		b = strconv.AppendUint(b, r.Fingerprint, 16)
+		b = append(b, '"')
+	}
+
+	for _, f := range r.Fields {
+		b = append(b, ',')
+		b = appendJSON(b, f.Key)
+		b = append(b, ':')
+		b = appendJSON(b, f.Value)
+	}
+
+	return append(b, "}\n"...)
+}
+
+// ParseJSON decodes a line written by JSONFormat back into a Record. Keys
+// other than the built-in ones become fields, in their original order, with
+// numbers decoded as json.Number.
+func ParseJSON(line []byte) (*Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok != json.Delim('{') {
+		return nil, errors.New("debug: record is not a JSON object")
+	}
+
+	r := &Record{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, _ := tok.(string)
+		switch key {
+		case "time":
+			var s string
+			if err = dec.Decode(&s); err == nil {
+				r.Time, err = time.Parse(time.RFC3339Nano, s)
+			}
+		case "name":
+			err = dec.Decode(&r.Name)
+		case "id":
+			err = dec.Decode(&r.ID)
+		case "level":
+			var s string
+			if err = dec.Decode(&s); err == nil {
+				r.Level, err = ParseLevel(s)
+			}
+		case "message":
+			err = dec.Decode(&r.Message)
+		case "global":
+			err = dec.Decode(&r.Global)
+		case "delta":
+			err = dec.Decode(&r.Delta)
+		case "elapsed":
+			err = dec.Decode(&r.Elapsed)
+		case "fingerprint":
+			var s string
+			if err = dec.Decode(&s); err == nil {
+				r.Fingerprint, err = strconv.ParseUint(s, 16, 64)
+			}
+		default:
+			var v interface{}
+			err = dec.Decode(&v)
+			r.Fields = append(r.Fields, KV{key, v})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Append the JSON encoding of `v`, falling back to its string form for
+// values that cannot be marshalled.
+func appendJSON(b []byte, v interface{}) []byte {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		j, _ = json.Marshal(fmt.Sprint(v))
+	}
+
+	return append(b, j...)
+}
+
+// Abbreviate shortens `name` to at most `width` columns, see DisplayWidth,
+// by replacing leading segments with an ellipsis, for example
+// "a:b:c:d:handler" becomes "…:d:handler" for a width of 11. Segments are
+// delimited by the current separator, see SetSeparator. The last segment
+// is always kept, and a width of zero or less leaves the name untouched.
+func Abbreviate(name string, width int) string {
+	return abbreviate(name, width, Separator())
+}
+
+// Abbreviate `name` with segments delimited by `sep`.
+func abbreviate(name string, width int, sep string) string {
+	if width <= 0 || DisplayWidth(name) <= width {
+		return name
+	}
+
+	rest := name
+	for {
+		i := strings.Index(rest, sep)
+		if i < 0 {
+			break
+		}
+
+		rest = rest[i+len(sep):]
+		if DisplayWidth(rest)+1+DisplayWidth(sep) <= width {
+			break
+		}
+	}
+
+	if rest == name {
+		return name
+	}
+
+	return "…" + sep + rest
+}
@@ -0,0 +1,173 @@
+package debug
+
+import "bytes"
+import "errors"
+import "fmt"
+import "time"
+import "testing"
+
+func TestAbbreviate(t *testing.T) {
+	cases := []struct {
+		name  string
+		width int
+		want  string
+	}{
+		{"a:b:c:d:handler", 0, "a:b:c:d:handler"},
+		{"a:b:c:d:handler", 15, "a:b:c:d:handler"},
+		{"a:b:c:d:handler", 11, "…:d:handler"},
+		{"a:b:c:d:handler", 12, "…:d:handler"},
+		{"a:b:c:d:handler", 13, "…:c:d:handler"},
+		{"a:b:c:d:handler", 3, "…:handler"},
+		{"handler", 3, "handler"},
+	}
+
+	for _, c := range cases {
+		if got := Abbreviate(c.name, c.width); got != c.want {
+			t.Errorf("expected %q at width %d to be %q, got %q", c.name, c.width, c.want, got)
+		}
+	}
+}
+
+func TestNameWidth(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	SetNameWidth(11)
+	defer SetNameWidth(0)
+
+	Enable("a:*")
+	Debug("a:b:c:d:handler")("text")
+
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+	Debug("a:b:c:d:handler")("json")
+
+	str := buf.String()
+	assertContains(t, str, "…:d:handler\033[0m - text")
+	assertContains(t, str, `"name":"a:b:c:d:handler"`)
+}
+
+func TestPrettyFormat(t *testing.T) {
+	f := &prettyFormat{}
+
+	r := &Record{Name: "db", Level: LevelError, Message: "failed", Fields: []KV{
+		{"err", errors.New("timed out")},
+		{"query", "select 1"},
+		{"n", 2},
+	}}
+
+	got := string(f.Format(nil, r, ""))
+	want := `00:00:00.000 +0ns    db ERR failed err="timed out" query="select 1" n=2` + "\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	f.Format(nil, &Record{Name: "db:conn"}, "")
+	got = string(f.Format(nil, &Record{Name: "db", Message: "aligned"}, ""))
+	assertContains(t, got, " db      DBG aligned")
+
+	got = string(f.Format(nil, r, "34"))
+	assertContains(t, got, "\033[34mdb\033[0m")
+	assertContains(t, got, "\033[31mfailed\033[0m")
+	assertContains(t, got, "\033[2merr=\033[0m\033[31m\"timed out\"\033[0m")
+}
+
+func TestDurationFormat(t *testing.T) {
+	SetDurationFormat(Milliseconds(3))
+	defer SetDurationFormat(nil)
+
+	m.Lock()
+	b := TextFormat.Format(nil, &Record{Name: "dur", Global: 1500 * time.Microsecond, Delta: 2 * time.Second}, "")
+	m.Unlock()
+
+	assertContains(t, string(b), " 1.500ms 2000.000ms dur - ")
+}
+
+func TestFingerprint(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "fingerprint")()
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+
+	log := Named("fingerprint")
+	log.Printf("user %d logged in", 1)
+	log.Printf("user %d logged in", 2)
+	log.Printf("user %d logged out", 1)
+	log.Bytes([]byte("raw"))
+
+	var prints []uint64
+	for _, line := range bytes.SplitAfter(buf.Bytes(), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		r, err := ParseJSON(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prints = append(prints, r.Fingerprint)
+	}
+
+	if len(prints) != 4 || prints[0] == 0 || prints[0] != prints[1] || prints[0] == prints[2] || prints[3] != 0 {
+		t.Fatalf("unexpected fingerprints %x", prints)
+	}
+
+	if prints[0] != fingerprint("fingerprint", "user %d logged in") {
+		t.Fatalf("expected the fingerprint of the format")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	f, err := Layout("{ns} {badge} {msg} +{delta}{fields}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Record{Name: "db", Level: LevelWarn, Message: "slow", Delta: 3 * time.Millisecond, Fields: []KV{{"n", 2}}}
+	if got := string(f.Format(nil, r, "")); got != "db WRN slow +3ms n=2\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	assertContains(t, string(f.Format(nil, r, "34")), "\033[34mdb\033[0m")
+
+	if _, err := Layout("{ns} {when}"); err == nil {
+		t.Fatalf("expected an error for an unknown placeholder")
+	}
+}
+
+func TestTimeMode(t *testing.T) {
+	if got := string(appendElapsed(nil, 12*time.Second+345678)); got != "12.000345678" {
+		t.Fatalf("unexpected elapsed time %q", got)
+	}
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "clock")()
+	defer SetTimeMode(TimeWall)
+
+	SetTimeMode(TimeBoth)
+	Debug("clock")("both")
+	SetFormat(JSONFormat)
+	Debug("clock")("json")
+	SetFormat(TextFormat)
+	SetTimeMode(TimeWall)
+	Debug("clock")("wall")
+
+	lines := bytes.Split(buf.Bytes(), []byte("\n"))
+	if len(lines) != 4 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+
+	var wall, secs, nanos int
+	if n, _ := fmt.Sscanf(string(lines[0]), "%d:%d:%d.%d %d.%d", &wall, &wall, &wall, &wall, &secs, &nanos); n != 6 {
+		t.Fatalf("expected both timestamps in %q", lines[0])
+	}
+
+	r, err := ParseJSON(lines[1])
+	if err != nil || r.Elapsed <= 0 {
+		t.Fatalf("expected the elapsed time in %q, %v", lines[1], err)
+	}
+
+	assertNotContains(t, string(lines[2]), ".000000")
+}
@@ -0,0 +1,58 @@
+package debug
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// GroupFunction is a debug function grouping related lines, see Group.
+type GroupFunction func(format string, args ...interface{})
+
+// Argument passed by End to the group function.
+type groupEnd struct{}
+
+// End writes the summary line of the group.
+func (g GroupFunction) End() {
+	g("", groupEnd{})
+}
+
+// Group creates a debug function for `name` whose lines are indented as a
+// group, for multi-step operations which are hard to follow in dense
+// output. End writes a summary with the number of lines of the group and
+// the time since Group was called:
+//
+//	grp := debug.Group("migrate:step-3")
+//	grp("copying %d rows", n)
+//	grp("rebuilding indexes")
+//	grp.End()
+func Group(name string) GroupFunction {
+	n := Named(name)
+	start := time.Now()
+	var count atomic.Int64
+
+	return func(format string, args ...interface{}) {
+		if len(args) == 1 && args[0] == (groupEnd{}) {
+			if !n.Enabled() {
+				return
+			}
+
+			c, elapsed := count.Load(), time.Since(start)
+			msg := "end of group, " + strconv.FormatInt(c, 10) + " line"
+			if c != 1 {
+				msg += "s"
+			}
+
+			n.emit(LevelDebug, msg+" in "+elapsed.String(), withDuration([]KV{{"count", c}}, elapsed))
+			return
+		}
+
+		if !n.Enabled() {
+			n.stats.suppressed.Add(1)
+			return
+		}
+
+		count.Add(1)
+		n.log(0, LevelDebug, nil, "  "+format, args...)
+	}
+}
@@ -0,0 +1,31 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestGroup(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "migrate:*")()
+
+	grp := Group("migrate:step-3")
+	grp("copying %d rows", 10)
+	grp("rebuilding indexes")
+	grp.End()
+
+	str := buf.String()
+	assertContains(t, str, " -   copying 10 rows\n")
+	assertContains(t, str, " -   rebuilding indexes\n")
+	assertContains(t, str, " - end of group, 2 lines in ")
+	assertContains(t, str, " count=2 duration=")
+
+	buf.Reset()
+	Disable()
+	grp = Group("migrate:step-4")
+	grp("hidden")
+	grp.End()
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output when disabled, got %q", buf.String())
+	}
+}
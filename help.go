@@ -0,0 +1,109 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Whether DEBUG=help was given, in which case namespaces are listed as
+// they are registered.
+var helpRequested bool
+
+// Syntax and environment variables printed by PrintUsage.
+const usage = `Debug output is enabled with a pattern of namespaces separated by commas
+or spaces, for example DEBUG=http,db:*
+
+  *	matches anything, DEBUG=* enables everything
+  ?	matches a single character
+  {a,b}	matches either alternative, such as db:{pool,conn}
+  db:*	matches db and the namespaces below it
+  -db:pool	excludes the matching namespaces
+  raft=2	sets the verbosity of the matching namespaces, see V
+  \*	matches a special character literally
+  help	prints this message
+
+Environment variables:
+
+  DEBUG	the pattern
+  DEBUG_EXTRA	added to DEBUG
+  DEBUG_%s	added to DEBUG for this binary only
+  DEBUG_ORDERED	evaluates the pattern in order when set, see EnableOrdered
+  DEBUG_FORMAT	text, json, pretty, msgpack or a layout such as "{ns} {msg}"
+  DEBUG_COLORS	auto, always or never
+  DEBUG_CONFLICTS	warns about namespaces used by several packages
+`
+
+// PrintUsage writes the pattern syntax, the environment variables and the
+// registered namespaces with their descriptions to `w`, marking those
+// which are enabled. Setting DEBUG=help prints it at start-up, followed by
+// the namespaces as they are registered, after which the program
+// continues with the rest of the pattern.
+func PrintUsage(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, usage, envName(binaryName()))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	list := Descriptions()
+	if len(list) > 0 {
+		fmt.Fprintf(w, "\nNamespaces, enabled ones are marked with *:\n\n")
+	}
+
+	width := 0
+	for _, d := range list {
+		width = max(width, DisplayWidth(d.Name))
+	}
+
+	for _, d := range list {
+		mark := " "
+		if Named(d.Name).Enabled() {
+			mark = "*"
+		}
+
+		line := mark + " " + d.Name
+		if d.Doc != "" {
+			line += strings.Repeat(" ", width-DisplayWidth(d.Name)+2) + d.Doc
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove the "help" token from `pattern`, reporting whether it was there.
+func stripHelp(pattern string) (string, bool) {
+	var rest []string
+	found := false
+	for _, tok := range tokens(pattern) {
+		if tok == "help" {
+			found = true
+			continue
+		}
+		rest = append(rest, tok)
+	}
+
+	return strings.Join(rest, ","), found
+}
+
+// Print the usage at start-up and list the namespaces registered by the
+// packages initialized next, the lock must not be held.
+func printStartupHelp() {
+	PrintUsage(writer)
+	fmt.Fprintf(writer, "\nNamespaces, as they are registered:\n\n")
+	helpRequested = true
+}
+
+// List namespace `name` registered while DEBUG=help is set, the lock must
+// be held.
+func registeredHelp(name string) {
+	if helpRequested {
+		fmt.Fprintf(writer, "  %s\n", name)
+	}
+}
@@ -0,0 +1,36 @@
+package debug
+
+import "bytes"
+import "io"
+import "testing"
+
+func TestPrintUsage(t *testing.T) {
+	defer Swap(io.Discard, "usage:on")()
+	Describe("usage:on", "enabled namespace")
+	Describe("usage:off", "disabled namespace")
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	if err := PrintUsage(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	str := buf.String()
+	assertContains(t, str, "{a,b}")
+	assertContains(t, str, "DEBUG_EXTRA")
+	assertContains(t, str, "DEBUG_"+envName(binaryName()))
+	assertContains(t, str, "\n* usage:on ")
+	assertContains(t, str, "\n  usage:off ")
+	assertContains(t, str, "  disabled namespace\n* usage:on ")
+	assertNotContains(t, str, " \n")
+}
+
+func TestStripHelp(t *testing.T) {
+	if p, help := stripHelp("help,db:* http"); !help || p != "db:*,http" {
+		t.Fatalf("unexpected %q %v", p, help)
+	}
+
+	if p, help := stripHelp("db:*"); help || p != "db:*" {
+		t.Fatalf("unexpected %q %v", p, help)
+	}
+}
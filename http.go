@@ -0,0 +1,220 @@
+package debug
+
+import (
+	"context"
+	"io"
+	"net/http"
+	rdebug "runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// InstrumentMux returns a handler serving `mux` which traces the lifecycle
+// of requests in one namespace per route pattern, such as
+// "http:GET:/users/{id}": the route matched, the start and end of the
+// handler with the status and duration, and panics, which are logged at
+// LevelError and re-raised. Requests of disabled namespaces are served
+// without overhead beyond routing. As braces without a comma are literal in
+// patterns, route names are enabled as written, such as with
+// "http:GET:/users/{id}" or "http:*".
+//
+//	http.ListenAndServe(addr, debug.InstrumentMux(mux))
+func InstrumentMux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+
+		n := Named(routeName(pattern))
+		if !n.Enabled() {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		n.log(0, LevelDebug, nil, "routed %s %s to %q", r.Method, r.URL.Path, pattern)
+		n.log(0, LevelDebug, nil, "start")
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			// Re-panicking starts a new trace, so the stack of the panic
+			// is kept in the record. Aborted handlers are not errors.
+			if v := recover(); v != nil {
+				if v != http.ErrAbortHandler {
+					n.log(0, LevelError, []KV{{"stack", string(rdebug.Stack())}, {"duration", time.Since(start)}}, "panic: %v", v)
+				}
+				panic(v)
+			}
+		}()
+
+		mux.ServeHTTP(sw.wrap(), r)
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		n.log(0, LevelDebug, []KV{{"status", sw.status}, {"duration", time.Since(start)}}, "end")
+	})
+}
+
+// Return the namespace of route `pattern`.
+func routeName(pattern string) string {
+	sep := Separator()
+
+	if pattern == "" {
+		return "http" + sep + "unmatched"
+	}
+
+	return "http" + sep + strings.ReplaceAll(pattern, " ", sep)
+}
+
+// A ResponseWriter recording the status.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ReadFrom implements io.ReaderFrom, using the one of the wrapped writer
+// when it has one.
+func (w *statusWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+// Push implements http.Pusher, returning http.ErrNotSupported when the
+// wrapped writer does not.
+func (w *statusWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Return the writer given to handlers, which is also an http.Flusher or an
+// http.Hijacker when the wrapped writer is, so that handlers streaming
+// events or upgrading connections work the same while debugging.
+func (w *statusWriter) wrap() http.ResponseWriter {
+	f, flusher := w.ResponseWriter.(http.Flusher)
+	h, hijacker := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return struct {
+			*statusWriter
+			http.Flusher
+			http.Hijacker
+		}{w, f, h}
+	case flusher:
+		return struct {
+			*statusWriter
+			http.Flusher
+		}{w, f}
+	case hijacker:
+		return struct {
+			*statusWriter
+			http.Hijacker
+		}{w, h}
+	default:
+		return w
+	}
+}
+
+// RequestOptions configures RequestDebugger.
+type RequestOptions struct {
+	// Header enabling output for a request when sent with a true value
+	// such as "1", "X-Debug" when empty.
+	Header string
+
+	// Allow decides whether the header is honoured for a request, for
+	// example by checking the credentials of the client. The header is
+	// ignored when nil, so that it cannot be enabled by anyone.
+	Allow func(r *http.Request) bool
+}
+
+// Context key of the request namespace.
+type requestKey struct{}
+
+// Sequence of request ids.
+var requestID atomic.Uint64
+
+// RequestDebugger returns a handler serving `next` with a namespace of its
+// own for each request, such as "http:req:42", placed in the request
+// context and retrieved with RequestNamespace. It is enabled by the
+// pattern as usual, for example "http:req:*", or for a single request
+// when the client sends the header of `opts` and Allow accepts it, so that
+// one request can be debugged in production:
+//
+//	h := debug.RequestDebugger(mux, debug.RequestOptions{
+//		Allow: func(r *http.Request) bool { return isAdmin(r) },
+//	})
+//
+// The start and end of enabled requests are written with the status and
+// duration. Request namespaces are not registered, see Namespaces.
+func RequestDebugger(next http.Handler, opts RequestOptions) http.Handler {
+	header := opts.Header
+	if header == "" {
+		header = "X-Debug"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sep := Separator()
+		id := strconv.FormatUint(requestID.Add(1), 10)
+		n := newNamespace("http" + sep + "req" + sep + id)
+
+		if v, err := strconv.ParseBool(r.Header.Get(header)); err == nil && v && opts.Allow != nil {
+			n.always = opts.Allow(r)
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), requestKey{}, n))
+		if !n.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		n.log(0, LevelDebug, nil, "start %s %s", r.Method, r.URL.Path)
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw.wrap(), r)
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		n.log(0, LevelDebug, []KV{{"status", sw.status}, {"duration", time.Since(start)}}, "end")
+	})
+}
+
+// RequestNamespace returns the namespace of the request of `ctx`, see
+// RequestDebugger, or the "http:req" namespace outside of one.
+func RequestNamespace(ctx context.Context) *Namespace {
+	if n, ok := ctx.Value(requestKey{}).(*Namespace); ok {
+		return n
+	}
+	return Named("http" + Separator() + "req")
+}
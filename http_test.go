@@ -0,0 +1,157 @@
+package debug
+
+import "bytes"
+import "context"
+import "net/http"
+import "net/http/httptest"
+import "testing"
+
+func TestInstrumentMux(t *testing.T) {
+	if !enhancedPatterns() {
+		t.Skip("the mux does not support methods and wildcards in patterns")
+	}
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("http:*")
+	defer Disable()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(r.PathValue("id")))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	h := InstrumentMux(mux)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Code != http.StatusTeapot || w.Body.String() != "42" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	str := buf.String()
+	assertContains(t, str, `http:GET:/users/{id}`)
+	assertContains(t, str, `routed GET /users/42 to "GET /users/{id}"`)
+	assertContains(t, str, "end status=418 duration=")
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("expected the panic to be re-raised")
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+	assertContains(t, buf.String(), "panic: boom")
+	assertContains(t, buf.String(), "stack=")
+
+	buf.Reset()
+	func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	}()
+	assertNotContains(t, buf.String(), "panic:")
+}
+
+func TestInstrumentMuxFlusher(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "http:*")()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatalf("expected the writer to be an http.Flusher")
+		}
+		if _, ok := w.(http.Hijacker); ok {
+			t.Fatalf("expected the writer not to be an http.Hijacker")
+		}
+		w.Write([]byte("data"))
+		f.Flush()
+	})
+
+	w := httptest.NewRecorder()
+	InstrumentMux(mux).ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	if !w.Flushed {
+		t.Fatalf("expected the response to be flushed")
+	}
+	assertContains(t, buf.String(), "end status=200")
+}
+
+// Report whether http.ServeMux supports the patterns of Go 1.22, which
+// GODEBUG=httpmuxgo121=1 and GOPATH mode turn off.
+func enhancedPatterns() (ok bool) {
+	defer func() { recover() }()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /p/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ok = r.PathValue("id") == "1"
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/p/1", nil))
+	return ok
+}
+
+func TestRequestDebugger(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "")()
+
+	h := RequestDebugger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestNamespace(r.Context()).Printf("loading %s", r.URL.Query().Get("user"))
+		w.WriteHeader(http.StatusCreated)
+	}), RequestOptions{
+		Allow: func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "admin"
+		},
+	})
+
+	r := httptest.NewRequest("GET", "/users?user=tobi", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	r = httptest.NewRequest("GET", "/users?user=loki", nil)
+	r.Header.Set("X-Debug", "1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assertNotContains(t, buf.String(), "loading")
+
+	r = httptest.NewRequest("GET", "/users?user=jane", nil)
+	r.Header.Set("X-Debug", "1")
+	r.Header.Set("Authorization", "admin")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	str := buf.String()
+	assertContains(t, str, "http:req:")
+	assertContains(t, str, "start GET /users")
+	assertContains(t, str, "loading jane")
+	assertContains(t, str, "end status=201 duration=")
+	assertNotContains(t, str, "tobi")
+
+	for _, name := range Namespaces() {
+		if name != "http:req" && bytes.HasPrefix([]byte(name), []byte("http:req:")) {
+			t.Fatalf("expected request namespaces not to be registered, got %q", name)
+		}
+	}
+}
+
+func TestRequestDebuggerPattern(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "http:req:*")()
+
+	h := RequestDebugger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RequestNamespace(r.Context()).Printf("handled")
+	}), RequestOptions{})
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assertContains(t, buf.String(), "handled")
+
+	if name := RequestNamespace(context.Background()).Name(); name != "http:req" {
+		t.Fatalf("unexpected namespace %q outside of requests", name)
+	}
+}
@@ -0,0 +1,100 @@
+package debug
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Path of the journald native protocol socket.
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalSink is a Sink writing records to the systemd journal using its
+// native protocol. Levels are mapped to the PRIORITY field, the namespace
+// is stored as DEBUG_NAMESPACE and record fields as upper-cased journal
+// fields. Records must fit in a single datagram.
+type JournalSink struct {
+	conn       *net.UnixConn
+	identifier string
+	buf        []byte
+}
+
+// NewJournalSink connects to journald, logging as `identifier`. Add it with
+// AddSink.
+func NewJournalSink(identifier string) (*JournalSink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournalSink{conn: conn, identifier: identifier}, nil
+}
+
+// Write implements Sink.
+func (s *JournalSink) Write(r *Record) error {
+	s.buf = appendJournalRecord(s.buf[:0], s.identifier, r)
+	_, err := s.conn.Write(s.buf)
+	return err
+}
+
+// Close closes the connection to journald.
+func (s *JournalSink) Close() error {
+	return s.conn.Close()
+}
+
+// Append `r` in the journal export format.
+func appendJournalRecord(b []byte, identifier string, r *Record) []byte {
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(r.Level.Syslog()))
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", identifier)
+	b = appendJournalField(b, "DEBUG_NAMESPACE", r.Name)
+	b = appendJournalField(b, "MESSAGE", r.Name+" - "+r.Message)
+
+	for _, f := range r.Fields {
+		if key := journalKey(f.Key); key != "" {
+			b = appendJournalField(b, key, fmt.Sprint(f.Value))
+		}
+	}
+
+	return b
+}
+
+// Append a single field, using the length-prefixed form for multi-line
+// values.
+func appendJournalField(b []byte, key, value string) []byte {
+	b = append(b, key...)
+
+	if !strings.Contains(value, "\n") {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// Convert `key` to a valid journal field name, or "" when impossible.
+func journalKey(key string) string {
+	key = strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return unicode.ToUpper(c)
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			return c
+		default:
+			return '_'
+		}
+	}, key)
+
+	key = strings.TrimLeft(key, "_")
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return ""
+	}
+
+	return key
+}
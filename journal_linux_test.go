@@ -0,0 +1,24 @@
+package debug
+
+import "testing"
+
+func TestJournalRecord(t *testing.T) {
+	r := &Record{
+		Name:    "db:conn",
+		Level:   LevelWarn,
+		Message: "one\ntwo",
+		Fields:  []KV{{"retry-count", 3}, {"_hidden", 1}, {"9lives", 1}},
+	}
+
+	got := string(appendJournalRecord(nil, "app", r))
+	want := "PRIORITY=4\n" +
+		"SYSLOG_IDENTIFIER=app\n" +
+		"DEBUG_NAMESPACE=db:conn\n" +
+		"MESSAGE\n\x11\x00\x00\x00\x00\x00\x00\x00db:conn - one\ntwo\n" +
+		"RETRY_COUNT=3\n" +
+		"HIDDEN=1\n"
+
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,97 @@
+package debug
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fields added to every record, see SetKubernetesFields. The lock must be
+// held.
+var processFields []KV
+
+// Directories of the Downward API volume and of the service account, read
+// by SetKubernetesFields.
+var (
+	podinfoDir        = "/etc/podinfo"
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// SetKubernetesFields adds the pod, Kubernetes namespace and node of the
+// process to every record, as "pod", "k8s_namespace" and "node" fields,
+// so that debug output collected across a fleet can be correlated. They
+// are read once from the Downward API, as the POD_NAME, POD_NAMESPACE and
+// NODE_NAME environment variables, or with a MY_ prefix as in the
+// Kubernetes documentation, or as files of the same names in lower case
+// in a volume mounted at /etc/podinfo:
+//
+//	env:
+//	- name: POD_NAME
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: metadata.name
+//	- name: NODE_NAME
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: spec.nodeName
+//
+// Without them, the namespace is read from the service account, and the
+// pod name is the hostname. Fields which cannot be found are left out.
+//
+// This function is thread-safe.
+func SetKubernetesFields(on bool) {
+	var fields []KV
+	if on {
+		fields = kubernetesFields(os.Getenv)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	processFields = fields
+}
+
+// Return the Kubernetes fields of the process, looking up environment
+// variables with `getenv`.
+func kubernetesFields(getenv func(string) string) []KV {
+	pod := downward(getenv, "POD_NAME")
+	if pod == "" && getenv("KUBERNETES_SERVICE_HOST") != "" {
+		pod = getenv("HOSTNAME")
+	}
+
+	ns := downward(getenv, "POD_NAMESPACE")
+	if ns == "" {
+		ns = readTrimmed(filepath.Join(serviceAccountDir, "namespace"))
+	}
+
+	var fields []KV
+	for _, kv := range []KV{{"pod", pod}, {"k8s_namespace", ns}, {"node", downward(getenv, "NODE_NAME")}} {
+		if kv.Value != "" {
+			fields = append(fields, kv)
+		}
+	}
+	return fields
+}
+
+// Return the Downward API value `name` from the environment or the
+// podinfo volume.
+func downward(getenv func(string) string, name string) string {
+	if v := getenv(name); v != "" {
+		return v
+	}
+
+	if v := getenv("MY_" + name); v != "" {
+		return v
+	}
+
+	return readTrimmed(filepath.Join(podinfoDir, strings.ToLower(name)))
+}
+
+// Return the content of the file `path` without surrounding white space,
+// or an empty string when it cannot be read.
+func readTrimmed(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,65 @@
+package debug
+
+import "bytes"
+import "os"
+import "path/filepath"
+import "testing"
+
+func TestKubernetesFields(t *testing.T) {
+	dir := t.TempDir()
+	defer func(p, s string) { podinfoDir, serviceAccountDir = p, s }(podinfoDir, serviceAccountDir)
+	podinfoDir = filepath.Join(dir, "podinfo")
+	serviceAccountDir = filepath.Join(dir, "serviceaccount")
+
+	os.Mkdir(podinfoDir, 0755)
+	os.Mkdir(serviceAccountDir, 0755)
+	os.WriteFile(filepath.Join(podinfoDir, "node_name"), []byte("node-1\n"), 0644)
+	os.WriteFile(filepath.Join(serviceAccountDir, "namespace"), []byte("prod"), 0644)
+
+	env := map[string]string{
+		"MY_POD_NAME": "api-7d4b9",
+		"HOSTNAME":    "ignored",
+	}
+
+	got := kubernetesFields(func(k string) string { return env[k] })
+	want := []KV{{"pod", "api-7d4b9"}, {"k8s_namespace", "prod"}, {"node", "node-1"}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	env = map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"HOSTNAME":                "api-7d4b9",
+		"POD_NAMESPACE":           "staging",
+	}
+	os.Remove(filepath.Join(podinfoDir, "node_name"))
+
+	got = kubernetesFields(func(k string) string { return env[k] })
+	if len(got) != 2 || got[0] != (KV{"pod", "api-7d4b9"}) || got[1] != (KV{"k8s_namespace", "staging"}) {
+		t.Fatalf("unexpected fields %v", got)
+	}
+}
+
+func TestSetKubernetesFields(t *testing.T) {
+	defer func(d string) { serviceAccountDir = d }(serviceAccountDir)
+	serviceAccountDir = t.TempDir()
+	os.WriteFile(filepath.Join(serviceAccountDir, "namespace"), []byte("prod"), 0644)
+
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "k8s")()
+
+	SetKubernetesFields(true)
+	Named("k8s").With(KV{"a", 1}).Printf("hello")
+	SetKubernetesFields(false)
+	Named("k8s").Printf("bye")
+
+	assertContains(t, buf.String(), "hello a=1 ")
+	assertContains(t, buf.String(), " k8s_namespace=prod")
+	assertNotContains(t, buf.String(), "bye k8s_namespace")
+}
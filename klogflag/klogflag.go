@@ -0,0 +1,141 @@
+// Package klogflag registers glog and klog compatible -v and -vmodule
+// flags, mapped onto debug patterns, so projects migrating from glog can
+// keep their runbooks:
+//
+//	klogflag.Register(nil)
+//	flag.Parse()
+//
+// With -v=2 every namespace is enabled with verbosity 2, and -vmodule
+// entries such as "raft=3,http*=1" name namespaces rather than files.
+// As in glog, -vmodule entries take precedence over -v, and -v=0 leaves
+// plain debug output disabled.
+package klogflag
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tj/go-debug"
+)
+
+// State shared by the flags.
+type state struct {
+	m       sync.Mutex
+	v       int
+	vmodule string
+}
+
+// Register adds the -v and -vmodule flags to `fs`, or flag.CommandLine
+// when nil. Setting either flag applies the resulting pattern with
+// debug.EnableOrdered.
+func Register(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	s := &state{}
+	fs.Var(&level{s}, "v", "number for the log level verbosity")
+	fs.Var(&module{s}, "vmodule", "comma-separated list of pattern=N settings for namespace-filtered logging")
+}
+
+// Pattern returns the debug pattern for verbosity `v` and a -vmodule
+// specification.
+func Pattern(v int, vmodule string) string {
+	var tokens []string
+	if v > 0 {
+		tokens = append(tokens, "*="+strconv.Itoa(v))
+	}
+
+	for _, tok := range strings.Split(vmodule, ",") {
+		if tok = strings.TrimSpace(tok); tok != "" {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return strings.Join(tokens, ",")
+}
+
+// Apply the pattern of the current flag values.
+func (s *state) apply() {
+	if p := Pattern(s.v, s.vmodule); p != "" {
+		debug.EnableOrdered(p)
+	} else {
+		debug.Disable()
+	}
+}
+
+// The -v flag.
+type level struct {
+	s *state
+}
+
+// String implements flag.Value.
+func (l *level) String() string {
+	if l.s == nil {
+		return "0"
+	}
+
+	l.s.m.Lock()
+	defer l.s.m.Unlock()
+	return strconv.Itoa(l.s.v)
+}
+
+// Set implements flag.Value.
+func (l *level) Set(value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	l.s.m.Lock()
+	defer l.s.m.Unlock()
+	l.s.v = v
+	l.s.apply()
+	return nil
+}
+
+// The -vmodule flag.
+type module struct {
+	s *state
+}
+
+// String implements flag.Value.
+func (m *module) String() string {
+	if m.s == nil {
+		return ""
+	}
+
+	m.s.m.Lock()
+	defer m.s.m.Unlock()
+	return m.s.vmodule
+}
+
+// Set implements flag.Value.
+func (m *module) Set(value string) error {
+	for _, tok := range strings.Split(value, ",") {
+		if tok = strings.TrimSpace(tok); tok == "" {
+			continue
+		}
+
+		i := strings.LastIndex(tok, "=")
+		if i < 0 {
+			return errSyntax
+		}
+
+		if _, err := strconv.Atoi(tok[i+1:]); err != nil {
+			return errSyntax
+		}
+	}
+
+	m.s.m.Lock()
+	defer m.s.m.Unlock()
+	m.s.vmodule = value
+	m.s.apply()
+	return nil
+}
+
+// Error returned for malformed -vmodule values.
+var errSyntax = errors.New("syntax error: expect comma-separated list of pattern=N")
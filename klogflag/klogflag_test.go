@@ -0,0 +1,61 @@
+package klogflag
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/tj/go-debug"
+)
+
+func TestPattern(t *testing.T) {
+	cases := []struct {
+		v       int
+		vmodule string
+		want    string
+	}{
+		{0, "", ""},
+		{2, "", "*=2"},
+		{0, "raft=3", "raft=3"},
+		{1, "raft=3, http*=0", "*=1,raft=3,http*=0"},
+	}
+
+	for _, c := range cases {
+		if got := Pattern(c.v, c.vmodule); got != c.want {
+			t.Errorf("expected -v=%d -vmodule=%q to map to %q, got %q", c.v, c.vmodule, c.want, got)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	var buf bytes.Buffer
+	debug.SetWriter(&buf)
+	defer debug.Disable()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	Register(fs)
+
+	if err := fs.Parse([]string{"-v=1", "-vmodule=raft=3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	debug.V("raft", 3)("raft three")
+	debug.V("http", 1)("http one")
+	debug.V("http", 2)("http two")
+
+	str := buf.String()
+	for _, s := range []string{"raft three", "http one"} {
+		if !strings.Contains(str, s) {
+			t.Errorf("expected %q in output", s)
+		}
+	}
+
+	if strings.Contains(str, "http two") {
+		t.Errorf("unexpected output %q", str)
+	}
+
+	if err := fs.Set("vmodule", "raft"); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+}
@@ -0,0 +1,92 @@
+package debug
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Layout returns a human readable Formatter rendering records according
+// to `layout`, in which placeholders select the parts of the record and
+// their order, for example to drop the timestamps which container runtimes
+// already add:
+//
+//	f, err := debug.Layout("{ns} {msg} +{delta}{fields}")
+//
+// The placeholders are {time} in the current TimeMode, {elapsed} since the
+// start of the process, {ns} or {name}, {level}, {badge}, {msg} or
+// {message}, {delta}, {global}, {fingerprint} and {fields}, which renders
+// the fields as space-prefixed key=value pairs. Parts missing from the
+// layout are omitted, and other text is kept as is. Names are colored and
+// abbreviated as with TextFormat, and a newline ends each record.
+func Layout(layout string) (Formatter, error) {
+	parts := parseTemplate(layout)
+	for _, p := range parts {
+		if p.placeholder && !layoutPlaceholders[p.text] {
+			return nil, fmt.Errorf("debug: unknown layout placeholder %q", "{"+p.text+"}")
+		}
+	}
+
+	return layoutFormat{layout: layout, parts: parts}, nil
+}
+
+// Placeholders supported by Layout.
+var layoutPlaceholders = map[string]bool{
+	"time":        true,
+	"ns":          true,
+	"name":        true,
+	"level":       true,
+	"badge":       true,
+	"msg":         true,
+	"message":     true,
+	"elapsed":     true,
+	"delta":       true,
+	"global":      true,
+	"fingerprint": true,
+	"fields":      true,
+}
+
+type layoutFormat struct {
+	layout string
+	parts  []templatePart
+}
+
+// Format implements Formatter.
+func (l layoutFormat) Format(b []byte, r *Record, color string) []byte {
+	for _, p := range l.parts {
+		if !p.placeholder {
+			b = append(b, p.text...)
+			continue
+		}
+
+		switch p.text {
+		case "time":
+			b = appendTime(b, r)
+		case "ns", "name":
+			b = appendColor(b, color)
+			b = append(b, abbreviate(r.Name, int(nameWidth.Load()), Separator())...)
+			b = appendReset(b, color)
+		case "level":
+			b = append(b, r.Level.String()...)
+		case "badge":
+			if color != "" {
+				b = appendColor(b, r.Level.Color())
+			}
+			b = append(b, r.Level.Badge()...)
+			b = appendReset(b, color)
+		case "msg", "message":
+			b = append(b, r.Message...)
+		case "elapsed":
+			b = appendElapsed(b, r.Elapsed)
+		case "delta":
+			b = appendDuration(b, r.Delta)
+		case "global":
+			b = appendDuration(b, r.Global)
+		case "fingerprint":
+			b = strconv.AppendUint(b, r.Fingerprint, 16)
+		case "fields":
+			b = appendFields(b, r.Fields)
+		}
+	}
+
+	return append(b, '\n')
+}
@@ -0,0 +1,68 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestLevels(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("levels")
+
+	log := Named("levels")
+	log.Printf("plain")
+	log.Warnf("careful")
+	log.Errorf("broken")
+
+	str := buf.String()
+	assertContains(t, str, "levels\033[0m - plain")
+	assertContains(t, str, "levels\033[0m \033[33mWRN\033[0m - careful")
+	assertContains(t, str, "\033[31mERR\033[0m - broken")
+}
+
+func TestLevelPrefixes(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("prefixes")
+	SetLevelPrefixes(true)
+	defer SetLevelPrefixes(false)
+
+	log := Debug("prefixes")
+	log("ERROR: connection lost")
+	log("[warn] retrying")
+	log("!info ready")
+	log("!unknown stays")
+	log("note: stays")
+	log("DEBUG: stays")
+
+	str := buf.String()
+	assertContains(t, str, "\033[31mERR\033[0m - connection lost")
+	assertContains(t, str, "\033[33mWRN\033[0m - retrying")
+	assertContains(t, str, "\033[36mINF\033[0m - ready")
+	assertContains(t, str, "- !unknown stays")
+	assertContains(t, str, "- note: stays")
+	assertContains(t, str, "- DEBUG: stays")
+}
+
+func TestLevelRoundTrip(t *testing.T) {
+	for _, l := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		for _, s := range []string{l.String(), l.Badge()} {
+			if p, err := ParseLevel(s); err != nil || p != l {
+				t.Errorf("expected %q to parse as %v, got %v (%v)", s, l, p, err)
+			}
+		}
+
+		r := &Record{Level: l}
+		j, err := ParseJSON(JSONFormat.Format(nil, r, ""))
+		if err != nil || j.Level != l {
+			t.Errorf("expected JSON level %v, got %v (%v)", l, j.Level, err)
+		}
+	}
+
+	if l := LevelWarn; l.Syslog() != 4 || l.Severity() != "WARNING" {
+		t.Fatalf("unexpected mapping for %v", l)
+	}
+}
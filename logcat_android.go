@@ -0,0 +1,64 @@
+//go:build cgo
+
+package debug
+
+/*
+#cgo LDFLAGS: -llog
+#include <android/log.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import "unsafe"
+
+// LogcatSink is a Sink writing records to the Android log, for gomobile
+// builds, viewable with `adb logcat`. The namespace is the tag, and levels
+// map to the priorities of the same name. It requires cgo.
+type LogcatSink struct {
+	tags map[string]*C.char
+	buf  []byte
+}
+
+// NewLogcatSink returns a sink writing to logcat. Add it with AddSink.
+func NewLogcatSink() *LogcatSink {
+	return &LogcatSink{tags: map[string]*C.char{}}
+}
+
+// Write implements Sink.
+func (s *LogcatSink) Write(r *Record) error {
+	tag, ok := s.tags[r.Name]
+	if !ok {
+		tag = C.CString(r.Name)
+		s.tags[r.Name] = tag
+	}
+
+	s.buf = append(s.buf[:0], r.Message...)
+	s.buf = appendFields(s.buf, r.Fields)
+	s.buf = append(s.buf, 0)
+
+	C.__android_log_write(logcatPriority(r.Level), tag, (*C.char)(unsafe.Pointer(&s.buf[0])))
+	return nil
+}
+
+// Close releases the tags.
+func (s *LogcatSink) Close() error {
+	for name, tag := range s.tags {
+		C.free(unsafe.Pointer(tag))
+		delete(s.tags, name)
+	}
+	return nil
+}
+
+// Return the logcat priority of `level`.
+func logcatPriority(level Level) C.int {
+	switch level {
+	case LevelInfo:
+		return C.ANDROID_LOG_INFO
+	case LevelWarn:
+		return C.ANDROID_LOG_WARN
+	case LevelError:
+		return C.ANDROID_LOG_ERROR
+	default:
+		return C.ANDROID_LOG_DEBUG
+	}
+}
@@ -0,0 +1,36 @@
+package debug
+
+// Matcher decides which namespaces are enabled, for custom logic such as
+// feature flags or A/B groups. It must be safe for concurrent use.
+type Matcher interface {
+	Match(name string) bool
+}
+
+// MatcherFunc adapts a function to a Matcher.
+type MatcherFunc func(name string) bool
+
+// Match implements Matcher.
+func (f MatcherFunc) Match(name string) bool {
+	return f(name)
+}
+
+// SetMatcher enables the namespaces matched by `mt` in place of the
+// pattern, at the default verbosity. Disable with patterns still excludes
+// namespaces on top of it, while Enable, EnableOrdered and Disable without
+// arguments return to pattern matching. A nil matcher disables output.
+//
+// This function is thread-safe.
+func SetMatcher(mt Matcher) {
+	m.Lock()
+	defer unlock()
+
+	var p *pattern
+	if mt != nil {
+		p = &pattern{custom: mt}
+	}
+
+	setActive(p)
+	current = ""
+	ordered = false
+	generation++
+}
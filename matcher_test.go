@@ -0,0 +1,42 @@
+package debug
+
+import "bytes"
+import "strings"
+import "testing"
+
+func TestSetMatcher(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "")()
+
+	SetMatcher(MatcherFunc(func(name string) bool {
+		return strings.HasSuffix(name, ":beta")
+	}))
+
+	Debug("checkout:beta")("beta group")
+	Debug("checkout:stable")("stable group")
+	assertContains(t, buf.String(), "beta group")
+	assertNotContains(t, buf.String(), "stable group")
+
+	Disable("checkout:*")
+	Debug("checkout:beta")("excluded")
+	Debug("search:beta")("still matched")
+	assertNotContains(t, buf.String(), "excluded")
+	assertContains(t, buf.String(), "still matched")
+
+	c := Config()
+	if c.Matcher == nil || c.Pattern != "-checkout:*" {
+		t.Fatalf("unexpected config %v", c)
+	}
+
+	Enable("checkout:stable")
+	Debug("checkout:stable")("pattern again")
+	Debug("search:beta")("not matched")
+	assertContains(t, buf.String(), "pattern again")
+	assertNotContains(t, buf.String(), "not matched")
+
+	SetMatcher(nil)
+	if Named("checkout:stable").Enabled() {
+		t.Fatalf("expected a nil matcher to disable output")
+	}
+}
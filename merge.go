@@ -0,0 +1,172 @@
+package debug
+
+import (
+	"container/heap"
+	"io"
+	"sort"
+	"time"
+)
+
+// Source is a stream of records to Merge.
+type Source struct {
+	// Reader of the records, which are expected in time order.
+	Reader *Reader
+
+	// Offset added to the time of each record, correcting for the clock
+	// skew of the host which wrote them.
+	Offset time.Duration
+
+	// Skew, when set, estimates a further correction per record, for
+	// clocks whose skew drifts over the recording.
+	Skew Skew
+
+	// Name, when set, is added to each record as a "source" field.
+	Name string
+}
+
+// Skew estimates the correction to add to the time of a record, see
+// InterpolateSkew.
+type Skew func(r *Record) time.Duration
+
+// SkewSample is an offset of a clock measured at a time, for example by
+// comparing it with an NTP server.
+type SkewSample struct {
+	Time   time.Time
+	Offset time.Duration
+}
+
+// InterpolateSkew returns a Skew interpolating linearly between `samples`,
+// and using the nearest one before the first or after the last, so that
+// measures taken at the start and end of a recording correct the drift of
+// the clock in between.
+func InterpolateSkew(samples ...SkewSample) Skew {
+	samples = append([]SkewSample(nil), samples...)
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+
+	return func(r *Record) time.Duration {
+		if len(samples) == 0 {
+			return 0
+		}
+
+		i := sort.Search(len(samples), func(i int) bool {
+			return !samples[i].Time.Before(r.Time)
+		})
+
+		switch i {
+		case 0:
+			return samples[0].Offset
+		case len(samples):
+			return samples[i-1].Offset
+		}
+
+		a, b := samples[i-1], samples[i]
+		f := float64(r.Time.Sub(a.Time)) / float64(b.Time.Sub(a.Time))
+		return a.Offset + time.Duration(f*float64(b.Offset-a.Offset))
+	}
+}
+
+// Merger reads the records of several sources in time order.
+type Merger struct {
+	sources []Source
+	heads   mergeHeap
+	started bool
+}
+
+// Merge returns a Merger interleaving the records of `sources` by time, for
+// example to reconstruct an incident from the recordings of several
+// services. Records with equal times are returned in the order of the
+// sources.
+//
+// Records whose time was corrected have a "skew" field with the correction
+// applied, after the "source" field naming their source, so that ordering
+// sensitive analysis can tell the observed order from the estimated one.
+func Merge(sources ...Source) *Merger {
+	return &Merger{sources: sources}
+}
+
+// Read returns the next record, or io.EOF once every source is exhausted.
+func (m *Merger) Read() (*Record, error) {
+	if !m.started {
+		m.started = true
+		for i := range m.sources {
+			if err := m.next(i); err != nil {
+				return nil, err
+			}
+		}
+		heap.Init(&m.heads)
+	}
+
+	if len(m.heads) == 0 {
+		return nil, io.EOF
+	}
+
+	head := heap.Pop(&m.heads).(mergeHead)
+	if err := m.next(head.source); err != nil {
+		return nil, err
+	}
+
+	return head.record, nil
+}
+
+// Push the next record of source `i`, if any.
+func (m *Merger) next(i int) error {
+	s := m.sources[i]
+
+	r, err := s.Reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	skew := s.Offset
+	if s.Skew != nil {
+		skew += s.Skew(r)
+	}
+
+	r.Time = r.Time.Add(skew)
+	if s.Name != "" {
+		r.Fields = append(r.Fields, KV{"source", s.Name})
+	}
+
+	if skew != 0 {
+		r.Fields = append(r.Fields, KV{"skew", skew})
+	}
+
+	heap.Push(&m.heads, mergeHead{r, i})
+	return nil
+}
+
+// The next record of a source.
+type mergeHead struct {
+	record *Record
+	source int
+}
+
+// A heap of the next records of each source, earliest first.
+type mergeHeap []mergeHead
+
+func (h mergeHeap) Len() int      { return len(h) }
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h mergeHeap) Less(i, j int) bool {
+	a, b := h[i].record.Time, h[j].record.Time
+	if a.Equal(b) {
+		return h[i].source < h[j].source
+	}
+	return a.Before(b)
+}
+
+func (h *mergeHeap) Push(v interface{}) {
+	*h = append(*h, v.(mergeHead))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
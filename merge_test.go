@@ -0,0 +1,111 @@
+package debug
+
+import "bytes"
+import "io"
+import "testing"
+import "time"
+
+func TestMerge(t *testing.T) {
+	base := time.Unix(1414000000, 0)
+
+	recording := func(offsets ...int) *Reader {
+		var buf bytes.Buffer
+		rec, _ := NewRecorder(&buf, EncodingJSON)
+		for _, ms := range offsets {
+			rec.Write(&Record{Time: base.Add(time.Duration(ms) * time.Millisecond), Name: "x"})
+		}
+
+		rd, err := NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rd
+	}
+
+	m := Merge(
+		Source{Reader: recording(10, 30, 50), Name: "api"},
+		Source{Reader: recording(1020, 1040), Name: "db", Offset: -time.Second},
+		Source{Reader: recording(), Name: "empty"},
+	)
+
+	var got []string
+	for {
+		r, err := m.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, r.Time.Sub(base).String()+" "+r.Fields[0].Value.(string))
+	}
+
+	want := []string{"10ms api", "20ms db", "30ms api", "40ms db", "50ms api"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestMergeSkew(t *testing.T) {
+	base := time.Unix(1414000000, 0)
+
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf, EncodingJSON)
+	for _, s := range []int{0, 5, 10, 20} {
+		rec.Write(&Record{Time: base.Add(time.Duration(s) * time.Second), Name: "x"})
+	}
+
+	rd, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := Merge(Source{
+		Reader: rd,
+		Name:   "api",
+		Offset: time.Second,
+		Skew: InterpolateSkew(
+			SkewSample{base.Add(10 * time.Second), -2 * time.Second},
+			SkewSample{base, 0},
+		),
+	})
+
+	var got []time.Duration
+	for {
+		r, err := m.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Fields[0] != (KV{"source", "api"}) {
+			t.Fatalf("unexpected fields %v", r.Fields)
+		}
+
+		var skew time.Duration
+		if len(r.Fields) > 1 {
+			skew = r.Fields[1].Value.(time.Duration)
+		}
+		got = append(got, skew)
+	}
+
+	want := []time.Duration{time.Second, 0, -time.Second, -time.Second}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package debug
+
+import (
+	"runtime"
+	rdebug "runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Module fields state, see SetModuleFields.
+var (
+	moduleFields atomic.Bool
+	moduleM      sync.Mutex
+	modules      []*rdebug.Module
+	moduleCache  = map[uintptr][]KV{}
+)
+
+// SetModuleFields adds the path and version of the Go module of the calling
+// package to records, as "module" and "version" fields, so aggregated logs
+// reveal which dependency version produced a line. Versions come from the
+// build information of the binary, and the main module usually reports
+// "(devel)". Lines written with Bytes and Append are not annotated.
+func SetModuleFields(on bool) {
+	moduleM.Lock()
+	defer moduleM.Unlock()
+
+	if on && modules == nil {
+		if info, ok := rdebug.ReadBuildInfo(); ok {
+			modules = append(modules, &info.Main)
+			for _, dep := range info.Deps {
+				if dep.Replace != nil {
+					dep = &rdebug.Module{Path: dep.Path, Version: dep.Replace.Version, Sum: dep.Replace.Sum}
+				}
+				modules = append(modules, dep)
+			}
+		}
+	}
+
+	moduleFields.Store(on)
+}
+
+// Return `fields` with the module of call site `pc`, if known.
+func withModule(fields []KV, pc uintptr) []KV {
+	moduleM.Lock()
+	kvs, ok := moduleCache[pc]
+	if !ok {
+		kvs = lookupModule(pc)
+		moduleCache[pc] = kvs
+	}
+	moduleM.Unlock()
+
+	if kvs == nil {
+		return fields
+	}
+
+	return append(fields[:len(fields):len(fields)], kvs...)
+}
+
+// Return the fields of the module of call site `pc`, the lock must be held.
+func lookupModule(pc uintptr) []KV {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	frame, _ := frames.Next()
+	pkg := packagePath(frame.Function)
+	if pkg == "" {
+		return nil
+	}
+
+	var best *rdebug.Module
+	for _, mod := range modules {
+		if mod.Path == "" || (pkg != mod.Path && !strings.HasPrefix(pkg, mod.Path+"/")) {
+			continue
+		}
+
+		if best == nil || len(mod.Path) > len(best.Path) {
+			best = mod
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	return []KV{{"module", best.Path}, {"version", best.Version}}
+}
+
+// Return the package path of the fully qualified function `name`, such as
+// "github.com/tj/go-debug" for "github.com/tj/go-debug.(*Namespace).Printf".
+func packagePath(name string) string {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return name[:slash+1+dot]
+}
@@ -0,0 +1,38 @@
+package debug
+
+import "bytes"
+import rdebug "runtime/debug"
+import "testing"
+
+func TestModuleFields(t *testing.T) {
+	// Without modules, such as in GOPATH mode, there is no main module.
+	if info, ok := rdebug.ReadBuildInfo(); !ok || info.Main.Path != "github.com/tj/go-debug" {
+		t.Skip("not built as the github.com/tj/go-debug module")
+	}
+
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("module")
+	SetModuleFields(true)
+	defer SetModuleFields(false)
+
+	Named("module").Printf("annotated")
+	assertContains(t, buf.String(), "annotated module=github.com/tj/go-debug version=")
+}
+
+func TestPackagePath(t *testing.T) {
+	cases := map[string]string{
+		"github.com/tj/go-debug.(*Namespace).Printf": "github.com/tj/go-debug",
+		"github.com/tj/go-debug/fake.New":            "github.com/tj/go-debug/fake",
+		"main.main.func1":                            "main",
+		"weird":                                      "",
+	}
+
+	for name, want := range cases {
+		if got := packagePath(name); got != want {
+			t.Errorf("expected %q for %q, got %q", want, name, got)
+		}
+	}
+}
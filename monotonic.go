@@ -0,0 +1,78 @@
+package debug
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TimeMode selects the timestamps of records, see SetTimeMode.
+type TimeMode int32
+
+// Time modes.
+const (
+	// TimeWall writes the wall-clock time of day, the default.
+	TimeWall TimeMode = iota
+
+	// TimeMonotonic writes the time elapsed since the process started,
+	// with nanosecond resolution, instead of the time of day.
+	TimeMonotonic
+
+	// TimeBoth writes the time of day followed by the elapsed time.
+	TimeBoth
+)
+
+// Reference of the monotonic clock, the start of the process.
+var processStart = time.Now()
+
+// Current TimeMode.
+var timeMode atomic.Int32
+
+// SetTimeMode selects the timestamps of records. The monotonic clock is
+// unaffected by wall-clock adjustments such as NTP jumps, and has the
+// resolution needed to measure tight loops. When enabled, records carry
+// the elapsed time in Record.Elapsed, which structured formats write as
+// "elapsed" in nanoseconds. Deltas are always measured with the monotonic
+// clock.
+func SetTimeMode(mode TimeMode) {
+	timeMode.Store(int32(mode))
+}
+
+// Return the time elapsed since the start of the process at `now` when
+// enabled, or zero.
+func elapsed(now time.Time) time.Duration {
+	if TimeMode(timeMode.Load()) == TimeWall {
+		return 0
+	}
+	return now.Sub(processStart)
+}
+
+// Append the timestamp of `r` in the current mode.
+func appendTime(b []byte, r *Record) []byte {
+	mode := TimeMode(timeMode.Load())
+	if mode != TimeMonotonic {
+		b = r.Time.UTC().AppendFormat(b, "15:04:05.000")
+	}
+
+	if mode == TimeWall {
+		return b
+	}
+
+	if mode == TimeBoth {
+		b = append(b, ' ')
+	}
+	return appendElapsed(b, r.Elapsed)
+}
+
+// Append `d` as seconds with nanosecond digits, such as "12.000345678".
+func appendElapsed(b []byte, d time.Duration) []byte {
+	ns := d.Nanoseconds()
+	b = strconv.AppendInt(b, ns/1e9, 10)
+	b = append(b, '.')
+
+	frac := ns % 1e9
+	for div := int64(1e8); div > frac && div > 1; div /= 10 {
+		b = append(b, '0')
+	}
+	return strconv.AppendInt(b, frac, 10)
+}
@@ -0,0 +1,547 @@
+package debug
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MsgpackFormat encodes each record as a MessagePack map, a compact
+// binary alternative to JSONFormat for high-volume network streams. The
+// map holds "time" as Unix nanoseconds, "name", the namespace "id",
+// "level" unless it is LevelDebug, "message", the "global" and "delta"
+// durations in nanoseconds, and when present the "elapsed" time in
+// nanoseconds, the "fingerprint" as a hexadecimal string and the "fields"
+// as a map. Decode it with a Decoder.
+var MsgpackFormat Formatter = msgpackFormat{}
+
+type msgpackFormat struct{}
+
+// Format implements Formatter.
+func (msgpackFormat) Format(b []byte, r *Record, color string) []byte {
+	size := 4
+	if r.Name != "" || r.ID == 0 {
+		size++
+	}
+	if r.ID != 0 {
+		size++
+	}
+	if r.Level != LevelDebug {
+		size++
+	}
+	if r.Elapsed != 0 {
+		size++
+	}
+	if r.Fingerprint != 0 {
+		size++
+	}
+	if len(r.Fields) > 0 {
+		size++
+	}
+
+	b = append(b, 0x80|byte(size))
+	b = appendMsgpackString(b, "time")
+	b = appendMsgpackInt(b, r.Time.UnixNano())
+	if r.Name != "" || r.ID == 0 {
+		b = appendMsgpackString(b, "name")
+		b = appendMsgpackString(b, r.Name)
+	}
+	if r.ID != 0 {
+		b = appendMsgpackString(b, "id")
+		b = appendMsgpackInt(b, int64(r.ID))
+	}
+	if r.Level != LevelDebug {
+		b = appendMsgpackString(b, "level")
+		b = appendMsgpackString(b, r.Level.String())
+	}
+	b = appendMsgpackString(b, "message")
+	b = appendMsgpackString(b, r.Message)
+	b = appendMsgpackString(b, "global")
+	b = appendMsgpackInt(b, int64(r.Global))
+	b = appendMsgpackString(b, "delta")
+	b = appendMsgpackInt(b, int64(r.Delta))
+	if r.Elapsed != 0 {
+		b = appendMsgpackString(b, "elapsed")
+		b = appendMsgpackInt(b, int64(r.Elapsed))
+	}
+	if r.Fingerprint != 0 {
+		// At most 16 digits, so the length fits the fixstr header.
+		b = appendMsgpackString(b, "fingerprint")
+		start := len(b)
+		b = strconv.AppendUint(append(b, 0), r.Fingerprint, 16)
+		b[start] = 0xa0 | byte(len(b)-start-1)
+	}
+
+	if len(r.Fields) > 0 {
+		b = appendMsgpackString(b, "fields")
+		b = appendMsgpackMapHeader(b, len(r.Fields))
+		for _, f := range r.Fields {
+			b = appendMsgpackString(b, f.Key)
+			b = appendMsgpackValue(b, f.Value)
+		}
+	}
+
+	return b
+}
+
+// Append a value, falling back to its string form for types without a
+// MessagePack representation.
+func appendMsgpackValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(v))
+	case int8:
+		return appendMsgpackInt(b, int64(v))
+	case int16:
+		return appendMsgpackInt(b, int64(v))
+	case int32:
+		return appendMsgpackInt(b, int64(v))
+	case int64:
+		return appendMsgpackInt(b, v)
+	case uint:
+		return appendMsgpackUint(b, uint64(v))
+	case uint8:
+		return appendMsgpackUint(b, uint64(v))
+	case uint16:
+		return appendMsgpackUint(b, uint64(v))
+	case uint32:
+		return appendMsgpackUint(b, uint64(v))
+	case uint64:
+		return appendMsgpackUint(b, v)
+	case float32:
+		return appendMsgpackFloat(b, float64(v))
+	case float64:
+		return appendMsgpackFloat(b, v)
+	case time.Duration:
+		return appendMsgpackInt(b, int64(v))
+	case string:
+		return appendMsgpackString(b, v)
+	case []byte:
+		b = appendMsgpackLen(b, len(v), 0xc4, 0xc5, 0xc6)
+		return append(b, v...)
+	case error:
+		return appendMsgpackString(b, v.Error())
+	default:
+		return appendMsgpackString(b, fmt.Sprint(v))
+	}
+}
+
+// Append an integer in its most compact form.
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendMsgpackUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// Append an unsigned integer in its most compact form.
+func appendMsgpackUint(b []byte, n uint64) []byte {
+	switch {
+	case n <= 0x7f:
+		return append(b, byte(n))
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+	}
+}
+
+// Append a float64.
+func appendMsgpackFloat(b []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+}
+
+// Append a string.
+func appendMsgpackString(b []byte, s string) []byte {
+	if len(s) < 32 {
+		b = append(b, 0xa0|byte(len(s)))
+	} else {
+		b = appendMsgpackLen(b, len(s), 0xd9, 0xda, 0xdb)
+	}
+	return append(b, s...)
+}
+
+// Append a map header.
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	if n < 16 {
+		return append(b, 0x80|byte(n))
+	}
+	if n <= math.MaxUint16 {
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+// Append a length with the 8, 16 or 32 bit type codes.
+func appendMsgpackLen(b []byte, n int, c8, c16, c32 byte) []byte {
+	switch {
+	case n <= math.MaxUint8:
+		return append(b, c8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, c16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, c32), uint32(n))
+	}
+}
+
+// Encoding names a record encoding for streams.
+type Encoding string
+
+// Encodings.
+const (
+	// EncodingJSON is a stream of JSONFormat lines.
+	EncodingJSON Encoding = "json"
+
+	// EncodingMsgpack is a stream of MsgpackFormat values.
+	EncodingMsgpack Encoding = "msgpack"
+
+	// EncodingCompactJSON and EncodingCompactMsgpack are streams of the
+	// CompactFormat of JSONFormat and MsgpackFormat, writing namespace
+	// IDs rather than names.
+	EncodingCompactJSON    Encoding = "json-compact"
+	EncodingCompactMsgpack Encoding = "msgpack-compact"
+)
+
+// Formatter returns the formatter producing the encoding. Compact
+// encodings return a new formatter on every call, for a single stream.
+func (e Encoding) Formatter() Formatter {
+	switch e {
+	case EncodingMsgpack:
+		return MsgpackFormat
+	case EncodingCompactJSON:
+		return CompactFormat(JSONFormat)
+	case EncodingCompactMsgpack:
+		return CompactFormat(MsgpackFormat)
+	default:
+		return JSONFormat
+	}
+}
+
+// Report whether the encoding is MessagePack rather than JSON lines.
+func (e Encoding) msgpack() bool {
+	return e == EncodingMsgpack || e == EncodingCompactMsgpack
+}
+
+// Decoder reads records from a stream written with JSONFormat or
+// MsgpackFormat.
+type Decoder struct {
+	r     *bufio.Reader
+	enc   Encoding
+	names map[uint32]string
+}
+
+// NewDecoder returns a Decoder reading records of encoding `enc` from `r`.
+// An empty encoding is treated as EncodingJSON.
+func NewDecoder(r io.Reader, enc Encoding) *Decoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &Decoder{r: br, enc: enc}
+}
+
+// Decode returns the next record, or io.EOF at the end of the stream. The
+// names of records written by CompactFormat are restored from the mapping
+// records, which are not returned.
+func (d *Decoder) Decode() (*Record, error) {
+	for {
+		r, err := d.decode()
+		if err != nil || r.ID == 0 {
+			return r, err
+		}
+
+		if r.Name == "" {
+			r.Name = d.names[r.ID]
+			return r, nil
+		}
+
+		if d.names == nil {
+			d.names = map[uint32]string{}
+		}
+		d.names[r.ID] = r.Name
+
+		if !isMapping(r) {
+			return r, nil
+		}
+	}
+}
+
+// Decode a single record.
+func (d *Decoder) decode() (*Record, error) {
+	if !d.enc.msgpack() {
+		line, err := readLine(d.r, maxFrame)
+		if err == io.EOF && len(line) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ParseJSON(line)
+	}
+
+	v, err := decodeMsgpack(d.r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return recordFromMap(v)
+}
+
+// Returned when a line is longer than the limit of readLine.
+var errLineTooLong = errors.New("debug: line too long")
+
+// Read a line of at most `max` bytes, including the newline, so that a
+// stream without newlines cannot exhaust memory.
+func readLine(r *bufio.Reader, max int) ([]byte, error) {
+	var line []byte
+	for {
+		b, err := r.ReadSlice('\n')
+		if len(line)+len(b) > max {
+			return nil, errLineTooLong
+		}
+
+		line = append(line, b...)
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// Maximum nesting of decoded MessagePack values.
+const maxMsgpackDepth = 64
+
+// Errors returned when decoding MessagePack.
+var errMsgpack = errors.New("debug: malformed msgpack record")
+
+// Build a record from a decoded MessagePack map.
+func recordFromMap(v interface{}) (*Record, error) {
+	kvs, ok := v.([]KV)
+	if !ok {
+		return nil, errMsgpack
+	}
+
+	r := &Record{}
+	for _, kv := range kvs {
+		switch kv.Key {
+		case "time":
+			n, _ := kv.Value.(int64)
+			r.Time = time.Unix(0, n)
+		case "name":
+			r.Name, _ = kv.Value.(string)
+		case "id":
+			n, _ := kv.Value.(int64)
+			r.ID = uint32(n)
+		case "level":
+			s, _ := kv.Value.(string)
+			r.Level, _ = ParseLevel(s)
+		case "message":
+			r.Message, _ = kv.Value.(string)
+		case "global":
+			n, _ := kv.Value.(int64)
+			r.Global = time.Duration(n)
+		case "delta":
+			n, _ := kv.Value.(int64)
+			r.Delta = time.Duration(n)
+		case "elapsed":
+			n, _ := kv.Value.(int64)
+			r.Elapsed = time.Duration(n)
+		case "fingerprint":
+			s, _ := kv.Value.(string)
+			r.Fingerprint, _ = strconv.ParseUint(s, 16, 64)
+		case "fields":
+			r.Fields, _ = kv.Value.([]KV)
+		default:
+			r.Fields = append(r.Fields, kv)
+		}
+	}
+
+	return r, nil
+}
+
+// Decode a single MessagePack value. Maps decode to []KV to preserve their
+// order, integers to int64 (or uint64 when too large) and arrays to
+// []interface{}. Values nested deeper than maxMsgpackDepth are malformed,
+// so that hostile streams cannot exhaust the stack.
+func decodeMsgpack(r *bufio.Reader, depth int) (interface{}, error) {
+	if depth > maxMsgpackDepth {
+		return nil, errMsgpack
+	}
+
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMsgpackMap(r, depth, uint64(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeMsgpackArray(r, depth, uint64(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeMsgpackString(r, uint64(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, n)
+	case 0xca:
+		n, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := readMsgpackUint(r, 1<<(c-0xcc))
+		if n > math.MaxInt64 {
+			return n, err
+		}
+		return int64(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := readMsgpackUint(r, size)
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(r, depth, n)
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(r, depth, n)
+	}
+
+	return nil, errMsgpack
+}
+
+// Read a big-endian unsigned integer of `size` bytes.
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	var n uint64
+	for i := 0; i < size; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// Read `n` bytes, growing the buffer as they are read rather than trusting
+// the length of the stream, which may be corrupted or hostile.
+func readMsgpackBytes(r *bufio.Reader, n uint64) ([]byte, error) {
+	if n > maxFrame {
+		return nil, errMsgpack
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode a string of `n` bytes.
+func decodeMsgpackString(r *bufio.Reader, n uint64) (interface{}, error) {
+	b, err := readMsgpackBytes(r, n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Decode an array of `n` values. Each value takes at least a byte, so
+// arrays longer than a frame are malformed.
+func decodeMsgpackArray(r *bufio.Reader, depth int, n uint64) (interface{}, error) {
+	if n > maxFrame {
+		return nil, errMsgpack
+	}
+
+	a := make([]interface{}, 0, min(n, 16))
+	for i := uint64(0); i < n; i++ {
+		v, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+// Decode a map of `n` entries with string keys.
+func decodeMsgpackMap(r *bufio.Reader, depth int, n uint64) (interface{}, error) {
+	if n > maxFrame {
+		return nil, errMsgpack
+	}
+
+	kvs := make([]KV, 0, min(n, 16))
+	for i := uint64(0); i < n; i++ {
+		k, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		kvs = append(kvs, KV{fmt.Sprint(k), v})
+	}
+	return kvs, nil
+}
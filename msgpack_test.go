@@ -0,0 +1,105 @@
+package debug
+
+import "bytes"
+import "io"
+import "math"
+import "testing"
+import "time"
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	in := &Record{
+		Time:    time.Unix(0, 1414000000123456789),
+		Name:    "mongo:conn",
+		Message: "a message which is longer than thirty one bytes",
+		Global:  -5,
+		Delta:   3 * time.Second,
+		Fields: []KV{
+			{"n", 300},
+			{"neg", -70000},
+			{"big", uint64(math.MaxUint64)},
+			{"f", 1.5},
+			{"ok", true},
+			{"nil", nil},
+			{"d", time.Millisecond},
+			{"other", []int{1, 2}},
+		},
+		Elapsed:     time.Hour,
+		Fingerprint: 0xfedcba9876543210,
+	}
+
+	var b []byte
+	b = MsgpackFormat.Format(b, in, "")
+	b = MsgpackFormat.Format(b, &Record{Name: "second"}, "")
+
+	dec := NewDecoder(bytes.NewReader(b), EncodingMsgpack)
+
+	out, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !out.Time.Equal(in.Time) || out.Name != in.Name || out.Message != in.Message || out.Global != in.Global || out.Delta != in.Delta || out.Elapsed != in.Elapsed || out.Fingerprint != in.Fingerprint {
+		t.Fatalf("unexpected record %+v", out)
+	}
+
+	want := []interface{}{int64(300), int64(-70000), uint64(math.MaxUint64), 1.5, true, nil, int64(time.Millisecond), "[1 2]"}
+	for i, f := range out.Fields {
+		if f.Key != in.Fields[i].Key || f.Value != want[i] {
+			t.Errorf("unexpected field %v, want %v", f, want[i])
+		}
+	}
+
+	out, err = dec.Decode()
+	if err != nil || out.Name != "second" {
+		t.Fatalf("unexpected second record %+v, %v", out, err)
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func BenchmarkJSONFormat(b *testing.B) {
+	benchmarkFormat(b, JSONFormat)
+}
+
+func BenchmarkMsgpackFormat(b *testing.B) {
+	benchmarkFormat(b, MsgpackFormat)
+}
+
+func benchmarkFormat(b *testing.B, f Formatter) {
+	r := &Record{
+		Time:    time.Now(),
+		Name:    "bench",
+		Message: "sending mail to tobi@segment.io",
+		Fields:  []KV{{"attempt", 3}, {"duration", time.Millisecond}},
+	}
+
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		buf = f.Format(buf[:0], r, "")
+	}
+}
+
+func TestMsgpackHostileLengths(t *testing.T) {
+	inputs := [][]byte{
+		{0xdd, 0x7f, 0xff, 0xff, 0xff},
+		{0xdf, 0x7f, 0xff, 0xff, 0xff},
+		{0xdb, 0xff, 0xff, 0xff, 0xff},
+		{0xc6, 0x7f, 0xff, 0xff, 0xff},
+		bytes.Repeat([]byte{0x91}, 1<<20),
+	}
+
+	for _, b := range inputs {
+		if r, err := NewDecoder(bytes.NewReader(b), EncodingMsgpack).Decode(); err == nil {
+			t.Errorf("expected an error decoding % x, got %+v", b[:min(len(b), 5)], r)
+		}
+	}
+}
+
+func TestDecoderLongLine(t *testing.T) {
+	line := bytes.Repeat([]byte{'x'}, maxFrame+1)
+	if _, err := NewDecoder(bytes.NewReader(line), EncodingJSON).Decode(); err != errLineTooLong {
+		t.Fatalf("expected a line too long error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package debug
+
+import "sync"
+
+// MutePolicy decides what happens to output while muted, see MuteWith.
+type MutePolicy int
+
+// Mute policies.
+const (
+	// MuteBuffer holds output back and writes it on unmute.
+	MuteBuffer MutePolicy = iota
+
+	// MuteDrop discards output.
+	MuteDrop
+)
+
+// Most output held back while muted, beyond which lines are dropped.
+const muteLimit = 1 << 20
+
+var (
+	muteBuffers int
+	muteDrops   int
+	muted       []byte
+)
+
+// Mute suppresses output to the writer until the returned function is
+// called, holding it back to be written then, for sections such as the
+// rendering of a progress bar which interleaved lines would garble:
+//
+//	unmute := debug.Mute()
+//	bar.Render()
+//	unmute()
+//
+// It is MuteWith(MuteBuffer).
+func Mute() (unmute func()) {
+	return MuteWith(MuteBuffer)
+}
+
+// MuteWith is like Mute with `policy`. Mutes nest: output is written again
+// once every one of them is undone, and dropped while any of them drops.
+// Up to 1MiB of output is held back. Sinks keep receiving records, as do
+// writers rendering records themselves, such as the browser console.
+//
+// This function is thread-safe.
+func MuteWith(policy MutePolicy) (unmute func()) {
+	m.Lock()
+	defer m.Unlock()
+
+	count := &muteBuffers
+	if policy == MuteDrop {
+		count = &muteDrops
+	}
+	*count++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.Lock()
+			defer m.Unlock()
+
+			*count--
+			if muteBuffers+muteDrops > 0 {
+				return
+			}
+
+			if len(muted) > 0 || status != "" {
+				writeOutput(writer, muted)
+			}
+			muted = nil
+		})
+	}
+}
+
+// Report whether output to the writer is muted, holding back `b` when it
+// is buffered. The lock must be held.
+func mute(b []byte) bool {
+	switch {
+	case muteDrops > 0:
+		return true
+	case muteBuffers > 0:
+		if len(muted)+len(b) <= muteLimit {
+			muted = append(muted, b...)
+		}
+		return true
+	}
+
+	return false
+}
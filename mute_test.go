@@ -0,0 +1,64 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestMute(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "progress")()
+
+	log := Debug("progress")
+	unmute := Mute()
+	log("while muted")
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output while muted, got %q", buf.String())
+	}
+
+	buf.WriteString("[=====>   ]\n")
+	unmute()
+	unmute()
+	log("after")
+
+	str := buf.String()
+	assertContains(t, str, "[=====>   ]\n")
+	assertContains(t, str, "while muted")
+	if i, j := bytes.Index(buf.Bytes(), []byte("while muted")), bytes.Index(buf.Bytes(), []byte("after")); i > j {
+		t.Fatalf("expected held back output first, got %q", str)
+	}
+}
+
+func TestMuteDrop(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "progress")()
+
+	log := Debug("progress")
+	outer := Mute()
+	inner := MuteWith(MuteDrop)
+	log("dropped")
+	inner()
+	log("held")
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output while muted, got %q", buf.String())
+	}
+
+	outer()
+	assertContains(t, buf.String(), "held")
+	assertNotContains(t, buf.String(), "dropped")
+}
+
+func TestMuteSinks(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "progress")()
+
+	s := &recordSink{}
+	defer AddSink(s)()
+
+	unmute := MuteWith(MuteDrop)
+	defer unmute()
+
+	Debug("progress")("recorded")
+	if len(s.records) != 1 {
+		t.Fatalf("expected sinks to receive records while muted, got %d", len(s.records))
+	}
+}
@@ -0,0 +1,194 @@
+package debug
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Hello is the first line sent by a NetSink, identifying the process
+// which the following records come from.
+type Hello struct {
+	Process  string   `json:"process"`
+	Pid      int      `json:"pid"`
+	Host     string   `json:"host,omitempty"`
+	Encoding Encoding `json:"encoding,omitempty"`
+}
+
+// DialOptions configures a NetSink.
+type DialOptions struct {
+	// Encoding of the records, EncodingJSON when empty. Compact encodings
+	// announce the namespaces again on every connection.
+	Encoding Encoding
+
+	// TLS, when set, secures the connection with TLS. Client certificates
+	// for servers which verify them are given in its Certificates.
+	TLS *tls.Config
+
+	// Key, when set, encrypts the stream with SealConn, which the server
+	// must be configured with as well. It may be combined with TLS.
+	Key []byte
+
+	// Timeout of connections and of each write, one second when zero.
+	// Sinks are written with the package lock held, so a slow listener
+	// would otherwise stall every debug call of the process.
+	Timeout time.Duration
+}
+
+// NetSink is a Sink streaming records over a network connection, as JSON
+// lines or MessagePack after a JSON Hello line, for example to the debugd
+// listener which multiplexes the output of several processes onto one
+// terminal. The connection is re-established on the next record after a
+// write fails, at most once per timeout, and records are dropped meanwhile.
+type NetSink struct {
+	network string
+	address string
+	hello   []byte
+	tls     *tls.Config
+	key     []byte
+	timeout time.Duration
+	enc     Encoding
+	format  Formatter
+
+	m     sync.Mutex
+	conn  net.Conn
+	buf   []byte
+	retry time.Time
+}
+
+// Dial connects a NetSink to `address` on `network`, for example "unix"
+// and "/tmp/debugd.sock". Add it with AddSink.
+func Dial(network, address string) (*NetSink, error) {
+	return DialWith(network, address, DialOptions{})
+}
+
+// DialWith is like Dial with options.
+func DialWith(network, address string, opts DialOptions) (*NetSink, error) {
+	if opts.Encoding == "" {
+		opts.Encoding = EncodingJSON
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+
+	hello, err := json.Marshal(newHello(opts.Encoding))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &NetSink{
+		network: network,
+		address: address,
+		hello:   append(hello, '\n'),
+		enc:     opts.Encoding,
+		tls:     opts.TLS,
+		key:     opts.Key,
+		timeout: opts.Timeout,
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Write implements Sink.
+func (s *NetSink) Write(r *Record) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	s.buf = s.format.Format(s.buf[:0], r, "")
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if _, err := s.conn.Write(s.buf); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.retry = time.Now().Add(s.timeout)
+		return err
+	}
+
+	return nil
+}
+
+// Close closes the connection.
+func (s *NetSink) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// Return the Hello of this process for records of encoding `enc`.
+func newHello(enc Encoding) Hello {
+	host, _ := os.Hostname()
+	return Hello{
+		Process:  filepath.Base(os.Args[0]),
+		Pid:      os.Getpid(),
+		Host:     host,
+		Encoding: enc,
+	}
+}
+
+// Dial and send the hello line, unless the last attempt failed less than
+// a timeout ago.
+func (s *NetSink) connect() error {
+	now := time.Now()
+	if now.Before(s.retry) {
+		return errReconnect
+	}
+	s.retry = now.Add(s.timeout)
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+
+	var conn net.Conn
+	var err error
+	if s.tls != nil {
+		conn, err = tls.DialWithDialer(dialer, s.network, s.address, s.tls)
+	} else {
+		conn, err = dialer.Dial(s.network, s.address)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.key != nil {
+		sealed, err := SealConn(conn, s.key)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		conn = sealed
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if _, err := conn.Write(s.hello); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	s.format = s.enc.Formatter()
+	s.retry = time.Time{}
+	return nil
+}
+
+// Returned while waiting to reconnect.
+var errReconnect = errors.New("debug: waiting to reconnect")
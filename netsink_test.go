@@ -0,0 +1,99 @@
+package debug
+
+import "bufio"
+import "net"
+import "testing"
+import "time"
+
+func TestNetSinkTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// The listener accepts, but never reads.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s, err := DialWith("tcp", l.Addr().String(), DialOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	r := &Record{Name: "x", Message: string(make([]byte, 1<<20))}
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if err := s.Write(r); err != nil {
+			break
+		}
+	}
+
+	if err := s.Write(r); err != errReconnect {
+		t.Fatalf("expected to wait before reconnecting, got %v", err)
+	}
+
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("expected writes to time out, took %s", d)
+	}
+}
+
+func TestNetSinkCompactReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// The listener sends the name of the first record of each connection.
+	names := make(chan string)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				if _, err := br.ReadSlice('\n'); err != nil {
+					return
+				}
+
+				r, err := NewDecoder(br, EncodingCompactJSON).Decode()
+				if err != nil {
+					names <- err.Error()
+					return
+				}
+				names <- r.Name
+			}()
+		}
+	}()
+
+	s, err := DialWith("tcp", l.Addr().String(), DialOptions{Encoding: EncodingCompactJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := s.Write(&Record{Name: "db", ID: 1, Message: "query"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if name := <-names; name != "db" {
+			t.Fatalf("expected the name on connection %d, got %q", i, name)
+		}
+		s.Close()
+	}
+}
@@ -0,0 +1,125 @@
+package debug
+
+import "sort"
+
+// A callback added by OnEnable.
+type enableCallback struct {
+	pat *pattern
+	fn  func(name string, enabled bool)
+}
+
+var (
+	callbacks     []*enableCallback
+	notifications []func()
+)
+
+// OnEnable calls `fn` whenever a registered namespace matching `pattern`
+// is enabled or disabled by a change of the pattern, so that subsystems
+// can run extra instrumentation only while they are being debugged. It is
+// called right away for the matching namespaces which are already
+// enabled, and for those registered later while enabled. Callbacks run
+// after the change is applied, in the goroutine making it, and may call
+// this package. Call the returned function to remove the callback.
+func OnEnable(pattern string, fn func(name string, enabled bool)) (remove func()) {
+	m.Lock()
+	defer unlock()
+
+	c := &enableCallback{compile(pattern, false), fn}
+	callbacks = append(callbacks, c)
+
+	if p := active.Load(); p != nil {
+		for _, name := range sortedNames() {
+			if c.pat.match(name) && p.match(name) {
+				notify(c, name, true)
+			}
+		}
+	}
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, v := range callbacks {
+			if v == c {
+				callbacks = append(callbacks[:i:i], callbacks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Replace the active pattern with `p`, queuing the callbacks of the
+// namespaces it toggles. The lock must be held, and released with unlock.
+func setActive(p *pattern) {
+	prev := active.Load()
+	active.Store(p)
+
+	if len(callbacks) == 0 {
+		return
+	}
+
+	for _, name := range sortedNames() {
+		was := prev != nil && prev.match(name)
+		now := p != nil && p.match(name)
+		if was == now {
+			continue
+		}
+
+		for _, c := range callbacks {
+			if c.pat.match(name) {
+				notify(c, name, now)
+			}
+		}
+	}
+}
+
+// Queue the callbacks of namespace `name` registered while enabled, the
+// lock must be held.
+func registered(name string) {
+	p := active.Load()
+	if len(callbacks) == 0 || p == nil || !p.match(name) {
+		return
+	}
+
+	for _, c := range callbacks {
+		if c.pat.match(name) {
+			notify(c, name, true)
+		}
+	}
+}
+
+// Queue a call of `c`, the lock must be held.
+func notify(c *enableCallback, name string, enabled bool) {
+	notifications = append(notifications, func() {
+		c.fn(name, enabled)
+	})
+}
+
+// Release the lock, save the pattern when persisted, and run the queued
+// callbacks.
+func unlock() {
+	queued := notifications
+	notifications = nil
+	save := pendingSave()
+	m.Unlock()
+
+	if save != nil {
+		save()
+	}
+
+	for _, fn := range queued {
+		fn()
+	}
+}
+
+// Return the names of the registered namespaces in order, the lock must be
+// held.
+func sortedNames() []string {
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,43 @@
+package debug
+
+import "io"
+import "strconv"
+import "testing"
+
+func TestOnEnable(t *testing.T) {
+	defer Swap(io.Discard, "stats:db")()
+	Named("stats:db")
+	Named("stats:http")
+
+	var calls []string
+	remove := OnEnable("stats:*", func(name string, enabled bool) {
+		calls = append(calls, name+"="+strconv.FormatBool(enabled))
+		Named(name).Printf("reentrant calls are fine")
+	})
+
+	Enable("stats:*,-stats:db")
+	Named("stats:cache")
+	Named("other")
+	Disable()
+	remove()
+	Enable("stats:*")
+
+	want := []string{
+		"stats:db=true",
+		"stats:db=false",
+		"stats:http=true",
+		"stats:cache=true",
+		"stats:cache=false",
+		"stats:http=false",
+	}
+
+	if len(calls) != len(want) {
+		t.Fatalf("expected %v, got %v", want, calls)
+	}
+
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, calls)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package debug
+
+import (
+	"context"
+	"sort"
+)
+
+// Option sets the level or adds fields to records: a Level overrides the
+// level, and Fields and KV values are added to the fields. Options are
+// given to With, or as trailing arguments of a DebugFunction.
+type Option interface {
+	option()
+}
+
+func (Level) option()  {}
+func (Fields) option() {}
+func (KV) option()     {}
+
+// Entry writes the records of a namespace with options, see With.
+type Entry struct {
+	n      *Namespace
+	level  Level
+	fields []KV
+}
+
+// With returns an Entry writing records of the namespace with `opts`:
+//
+//	log.With(debug.Fields{"ms": ms}, debug.LevelWarn).Printf("slow query %s", q)
+func (n *Namespace) With(opts ...Option) *Entry {
+	e := &Entry{n: n}
+	for _, opt := range opts {
+		e.level, e.fields = applyOption(opt, e.level, e.fields)
+	}
+	return e
+}
+
+// Printf is like the Printf method of the namespace, with the options of
+// the entry.
+func (e *Entry) Printf(format string, args ...interface{}) {
+	e.n.log(0, e.level, e.fields[:len(e.fields):len(e.fields)], format, args...)
+}
+
+// PrintfContext is like the PrintfContext method of the namespace, with
+// the options of the entry.
+func (e *Entry) PrintfContext(ctx context.Context, format string, args ...interface{}) {
+	e.n.logContext(0, ctx, e.level, e.fields, format, args...)
+}
+
+// Write a line of a DebugFunction, whose trailing arguments may be
+// options.
+func (n *Namespace) debugf(format string, args ...interface{}) {
+	n.logOptions(0, LevelDebug, nil, format, args)
+}
+
+// Format and write a line at `level` with `fields` and the trailing
+// options of `args`. `skip` is as for log.
+func (n *Namespace) logOptions(skip int, level Level, fields []KV, format string, args []interface{}) {
+	args, level, fields = options(format, args, level, fields)
+	n.log(skip+1, level, fields, format, args...)
+}
+
+// Split trailing options off `args`, applying them to `level` and
+// `fields`, so that calls of a DebugFunction can carry metadata without a
+// change of signature:
+//
+//	log("slow query %s", q, debug.Fields{"ms": ms}, debug.LevelWarn)
+//
+// Only arguments left over by the verbs of `format` are options, so that
+// a Level or KV printed by the format is not taken for one.
+func options(format string, args []interface{}, level Level, fields []KV) ([]interface{}, Level, []KV) {
+	if len(args) == 0 || !isOption(args[len(args)-1]) {
+		return args, level, fields
+	}
+
+	verbs := countVerbs(format)
+	if verbs < 0 {
+		return args, level, fields
+	}
+
+	i := len(args)
+	for i > verbs && isOption(args[i-1]) {
+		i--
+	}
+
+	if i == len(args) {
+		return args, level, fields
+	}
+
+	fields = fields[:len(fields):len(fields)]
+	for _, opt := range args[i:] {
+		level, fields = applyOption(opt.(Option), level, fields)
+	}
+
+	return args[:i], level, fields
+}
+
+// Apply `opt` to `level` and `fields`, adding Fields sorted by key.
+func applyOption(opt Option, level Level, fields []KV) (Level, []KV) {
+	switch v := opt.(type) {
+	case Level:
+		level = v
+	case KV:
+		fields = append(fields, v)
+	case Fields:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fields = append(fields, KV{k, v[k]})
+		}
+	}
+	return level, fields
+}
+
+// Check whether `v` is an option.
+func isOption(v interface{}) bool {
+	_, ok := v.(Option)
+	return ok
+}
+
+// Return the number of arguments consumed by the verbs of `format`, or -1
+// when it uses explicit argument indexes.
+func countVerbs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		for i++; i < len(format); i++ {
+			c := format[i]
+			if c == '[' {
+				return -1
+			}
+			if c == '*' {
+				n++
+				continue
+			}
+			if c == '%' {
+				break
+			}
+			if c == '+' || c == '-' || c == '#' || c == ' ' || c == '.' || ('0' <= c && c <= '9') {
+				continue
+			}
+			n++
+			break
+		}
+	}
+	return n
+}
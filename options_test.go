@@ -0,0 +1,94 @@
+package debug
+
+import "bytes"
+import "context"
+import "testing"
+
+func TestOptions(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "db")()
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+
+	log := Debug("db")
+	log("slow query %s", "select", Fields{"ms": 120, "table": "users"}, LevelWarn)
+	log("level is %v", LevelError)
+	log("plain", KV{"rows", 3})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+
+	r, err := ParseJSON(lines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Message != "slow query select" || r.Level != LevelWarn {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	if len(r.Fields) != 2 || r.Fields[0].Key != "ms" || r.Fields[1].Key != "table" {
+		t.Fatalf("unexpected fields %v", r.Fields)
+	}
+
+	r, _ = ParseJSON(lines[1])
+	if r.Message != "level is error" || r.Level != LevelDebug {
+		t.Fatalf("expected the level to be formatted, got %+v", r)
+	}
+
+	r, _ = ParseJSON(lines[2])
+	if r.Message != "plain" || len(r.Fields) != 1 || r.Fields[0].Key != "rows" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+}
+
+func TestOptionsContext(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "db")()
+
+	ctx := WithField(context.Background(), "tenant", "acme")
+	fields := ContextFields(ctx)
+
+	Named("db").With(KV{"rows", 3}, LevelInfo).PrintfContext(ctx, "query")
+	assertContains(t, buf.String(), "INF")
+	assertContains(t, buf.String(), "query tenant=acme rows=3")
+
+	if len(ContextFields(ctx)) != 1 || len(fields) != 1 {
+		t.Fatalf("expected the context fields to be unchanged, got %v", ContextFields(ctx))
+	}
+}
+
+func TestWith(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "db")()
+
+	log := Named("db").With(Fields{"table": "users"}, LevelWarn)
+	log.Printf("slow query %s", "select")
+	log.Printf("level is %v", LevelError)
+
+	assertContains(t, buf.String(), "WRN")
+	assertContains(t, buf.String(), "slow query select table=users")
+	assertContains(t, buf.String(), "level is error table=users")
+	assertNotContains(t, buf.String(), "table=users table=users")
+}
+
+func TestCountVerbs(t *testing.T) {
+	cases := map[string]int{
+		"":              0,
+		"plain":         0,
+		"%s":            1,
+		"%d%%":          1,
+		"%-8s %+.2f":    2,
+		"%*d":           2,
+		"%[1]s %[1]s":   -1,
+		"%v é %x trail": 2,
+	}
+
+	for format, n := range cases {
+		if got := countVerbs(format); got != n {
+			t.Errorf("countVerbs(%q) = %d, expected %d", format, got, n)
+		}
+	}
+}
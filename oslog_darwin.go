@@ -0,0 +1,67 @@
+//go:build darwin && cgo
+
+package debug
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static void debug_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// OSLogSink is a Sink writing records to the macOS unified logging system,
+// viewable in Console.app or with `log stream`. Namespaces are mapped to
+// categories of one subsystem, and levels to log types: LevelDebug to
+// debug, LevelInfo to info, LevelWarn to default and LevelError to error.
+// It requires cgo.
+type OSLogSink struct {
+	subsystem *C.char
+	logs      map[string]C.os_log_t
+	buf       []byte
+}
+
+// NewOSLogSink returns a sink logging to `subsystem`, such as
+// "com.example.daemon". Add it with AddSink.
+func NewOSLogSink(subsystem string) *OSLogSink {
+	return &OSLogSink{
+		subsystem: C.CString(subsystem),
+		logs:      map[string]C.os_log_t{},
+	}
+}
+
+// Write implements Sink.
+func (s *OSLogSink) Write(r *Record) error {
+	log, ok := s.logs[r.Name]
+	if !ok {
+		category := C.CString(r.Name)
+		log = C.os_log_create(s.subsystem, category)
+		C.free(unsafe.Pointer(category))
+		s.logs[r.Name] = log
+	}
+
+	s.buf = append(s.buf[:0], r.Message...)
+	s.buf = appendFields(s.buf, r.Fields)
+	s.buf = append(s.buf, 0)
+
+	C.debug_os_log(log, osLogType(r.Level), (*C.char)(unsafe.Pointer(&s.buf[0])))
+	return nil
+}
+
+// Return the log type of `level`.
+func osLogType(level Level) C.os_log_type_t {
+	switch level {
+	case LevelInfo:
+		return C.OS_LOG_TYPE_INFO
+	case LevelWarn:
+		return C.OS_LOG_TYPE_DEFAULT
+	case LevelError:
+		return C.OS_LOG_TYPE_ERROR
+	default:
+		return C.OS_LOG_TYPE_DEBUG
+	}
+}
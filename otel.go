@@ -0,0 +1,398 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPOptions configures an OTLPSink.
+type OTLPOptions struct {
+	// Endpoint of the collector, such as "http://localhost:4318", to which
+	// "/v1/logs" is added unless it has a path.
+	Endpoint string
+
+	// Header sent with each request, for example for authentication.
+	Header http.Header
+
+	// Resource attributes, with "service.name" set to the process name
+	// unless given.
+	Resource map[string]string
+
+	// Interval between exports, one second when zero.
+	Interval time.Duration
+
+	// Batch is the number of records exported at once, 512 when zero.
+	// Records beyond four batches awaiting export are dropped.
+	Batch int
+
+	// Client of the exports, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// OTLPSink is a Sink exporting records as OpenTelemetry log records over
+// OTLP/HTTP with JSON encoding, so that debug output goes through an
+// existing collector pipeline:
+//
+//	otlp := debug.NewOTLPSink(debug.OTLPOptions{Endpoint: "http://localhost:4318"})
+//	defer otlp.Close()
+//	debug.AddSink(otlp)
+//
+// The namespace of a record is the name of its instrumentation scope, and
+// its fields are attributes, except for "trace_id" and "span_id" which set
+// the trace context of the log record when they are hex strings.
+type OTLPSink struct {
+	opts     OTLPOptions
+	url      string
+	resource []otlpKV
+
+	m       sync.Mutex
+	records []*Record
+	dropped uint64
+	err     error
+	flush   chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewOTLPSink returns an OTLPSink exporting to the collector of `opts`.
+// Close it to export the remaining records.
+func NewOTLPSink(opts OTLPOptions) *OTLPSink {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	if opts.Batch <= 0 {
+		opts.Batch = 512
+	}
+
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	endpoint := opts.Endpoint
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		u.Path = "/v1/logs"
+		endpoint = u.String()
+	}
+
+	resource := map[string]string{"service.name": newHello("").Process}
+	for k, v := range opts.Resource {
+		resource[k] = v
+	}
+
+	s := &OTLPSink{
+		opts:     opts,
+		url:      endpoint,
+		resource: otlpAttributes(resource),
+		flush:    make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go s.run()
+	return s
+}
+
+// Write implements Sink, queueing a copy of `r` for export.
+func (s *OTLPSink) Write(r *Record) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(s.records) >= 4*s.opts.Batch {
+		s.dropped++
+		return nil
+	}
+
+	s.records = append(s.records, r.Clone())
+	if len(s.records) == s.opts.Batch {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Dropped returns the number of records dropped while the queue was full.
+func (s *OTLPSink) Dropped() uint64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.dropped
+}
+
+// Close exports the queued records and stops the sink, returning the error
+// of the last failed export, if any.
+func (s *OTLPSink) Close() error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.err
+}
+
+// Export the queued records every interval, or once a batch is full, until
+// stopped.
+func (s *OTLPSink) run() {
+	defer close(s.done)
+
+	t := time.NewTicker(s.opts.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-s.flush:
+		case <-s.stop:
+			for s.export() {
+			}
+			return
+		}
+
+		s.export()
+	}
+}
+
+// Export a batch of the queued records, reporting whether there was one.
+func (s *OTLPSink) export() bool {
+	s.m.Lock()
+	n := min(len(s.records), s.opts.Batch)
+	batch := s.records[:n:n]
+	s.records = s.records[n:]
+	s.m.Unlock()
+
+	if n == 0 {
+		return false
+	}
+
+	body, err := json.Marshal(otlpRequest(s.resource, batch))
+	if err == nil {
+		err = s.send(body)
+	}
+
+	if err != nil {
+		s.m.Lock()
+		s.err = err
+		s.m.Unlock()
+	}
+
+	return true
+}
+
+// Post `body` to the collector.
+func (s *OTLPSink) send(body []byte) error {
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range s.opts.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("debug: collector responded %s", res.Status)
+	}
+	return nil
+}
+
+// The JSON encoding of an ExportLogsServiceRequest, with a scope per
+// namespace in the order of their first record.
+type otlpExport struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKV `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string    `json:"timeUnixNano"`
+	ObservedTimeUnixNano string    `json:"observedTimeUnixNano"`
+	SeverityNumber       int       `json:"severityNumber"`
+	SeverityText         string    `json:"severityText"`
+	Body                 otlpValue `json:"body"`
+	Attributes           []otlpKV  `json:"attributes,omitempty"`
+	TraceID              string    `json:"traceId,omitempty"`
+	SpanID               string    `json:"spanId,omitempty"`
+}
+
+type otlpKV struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// An AnyValue, of which a single field is set. Integers are strings, as
+// in the JSON encoding of protobuf.
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// Return the export request of `records`.
+func otlpRequest(resource []otlpKV, records []*Record) otlpExport {
+	var scopes []otlpScopeLogs
+	index := map[string]int{}
+
+	observed := strconv.FormatInt(time.Now().UnixNano(), 10)
+	for _, r := range records {
+		i, ok := index[r.Name]
+		if !ok {
+			i = len(scopes)
+			index[r.Name] = i
+			scopes = append(scopes, otlpScopeLogs{Scope: otlpScope{r.Name}})
+		}
+
+		l := otlpLogRecord{
+			TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+			ObservedTimeUnixNano: observed,
+			SeverityNumber:       otlpSeverity(r.Level),
+			SeverityText:         strings.ToUpper(r.Level.String()),
+			Body:                 otlpString(r.Message),
+		}
+
+		for _, f := range r.Fields {
+			switch {
+			case f.Key == "trace_id" && isHex(f.Value, 32):
+				l.TraceID = strings.ToLower(f.Value.(string))
+			case f.Key == "span_id" && isHex(f.Value, 16):
+				l.SpanID = strings.ToLower(f.Value.(string))
+			default:
+				l.Attributes = append(l.Attributes, otlpKV{f.Key, otlpAnyValue(f.Value)})
+			}
+		}
+
+		scopes[i].LogRecords = append(scopes[i].LogRecords, l)
+	}
+
+	return otlpExport{[]otlpResourceLogs{{otlpResource{resource}, scopes}}}
+}
+
+// Return the severity number of level `l`, the first of its range.
+func otlpSeverity(l Level) int {
+	switch l {
+	case LevelInfo:
+		return 9
+	case LevelWarn:
+		return 13
+	case LevelError:
+		return 17
+	default:
+		return 5
+	}
+}
+
+// Return `attrs` as attributes, sorted by key.
+func otlpAttributes(attrs map[string]string) []otlpKV {
+	kvs := make([]otlpKV, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, otlpKV{k, otlpString(v)})
+	}
+
+	sort.Slice(kvs, func(i, j int) bool {
+		return kvs[i].Key < kvs[j].Key
+	})
+	return kvs
+}
+
+// Return `v` as an AnyValue, formatting values of other types than
+// strings, booleans and numbers.
+func otlpAnyValue(v interface{}) otlpValue {
+	var i int64
+	switch v := v.(type) {
+	case string:
+		return otlpString(v)
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint8:
+		i = int64(v)
+	case uint16:
+		i = int64(v)
+	case uint32:
+		i = int64(v)
+	case float32:
+		return otlpDouble(float64(v))
+	case float64:
+		return otlpDouble(v)
+	default:
+		return otlpString(fmt.Sprint(v))
+	}
+
+	s := strconv.FormatInt(i, 10)
+	return otlpValue{IntValue: &s}
+}
+
+func otlpString(s string) otlpValue {
+	return otlpValue{StringValue: &s}
+}
+
+// Return `f` as a double, or as a string when JSON cannot encode it.
+func otlpDouble(f float64) otlpValue {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return otlpString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return otlpValue{DoubleValue: &f}
+}
+
+// Check whether `v` is a string of `n` hex digits, not all zero.
+func isHex(v interface{}, n int) bool {
+	s, ok := v.(string)
+	if !ok || len(s) != n || strings.Count(s, "0") == n {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package debug
+
+import "bytes"
+import "encoding/json"
+import "net/http"
+import "net/http/httptest"
+import "testing"
+import "time"
+
+func TestOTLPSink(t *testing.T) {
+	requests := make(chan otlpExport, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Authorization") != "Bearer x" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var e otlpExport
+		json.NewDecoder(r.Body).Decode(&e)
+		requests <- e
+	}))
+	defer srv.Close()
+
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	s := NewOTLPSink(OTLPOptions{
+		Endpoint: srv.URL,
+		Header:   http.Header{"Authorization": {"Bearer x"}},
+		Resource: map[string]string{"deployment.environment": "test"},
+		Interval: time.Hour,
+	})
+	defer AddSink(s)()
+
+	Named("db").With(KV{"ms", 12}, KV{"trace_id", "4BF92F3577B34DA6A3CE929D0E0E4736"}, KV{"span_id", "00f067aa0ba902b7"}).Printf("query")
+	Named("http").With(KV{"ok", true}, KV{"ratio", 0.5}, LevelWarn).Printf("slow")
+	Named("db").With(KV{"trace_id", "nope"}, LevelError).Printf("closed")
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	e := <-requests
+	if len(e.ResourceLogs) != 1 {
+		t.Fatalf("unexpected export %+v", e)
+	}
+
+	rl := e.ResourceLogs[0]
+	if len(rl.Resource.Attributes) != 2 || rl.Resource.Attributes[0].Key != "deployment.environment" || rl.Resource.Attributes[1].Key != "service.name" {
+		t.Fatalf("unexpected resource %+v", rl.Resource)
+	}
+
+	if len(rl.ScopeLogs) != 2 || rl.ScopeLogs[0].Scope.Name != "db" || rl.ScopeLogs[1].Scope.Name != "http" {
+		t.Fatalf("unexpected scopes %+v", rl.ScopeLogs)
+	}
+
+	db := rl.ScopeLogs[0].LogRecords
+	if len(db) != 2 {
+		t.Fatalf("unexpected records %+v", db)
+	}
+
+	if l := db[0]; *l.Body.StringValue != "query" || l.SeverityNumber != 5 || l.SeverityText != "DEBUG" ||
+		l.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || l.SpanID != "00f067aa0ba902b7" ||
+		len(l.Attributes) != 1 || *l.Attributes[0].Value.IntValue != "12" || l.TimeUnixNano == "" {
+		t.Fatalf("unexpected record %+v", l)
+	}
+
+	if l := db[1]; l.SeverityNumber != 17 || l.TraceID != "" || *l.Attributes[0].Value.StringValue != "nope" {
+		t.Fatalf("unexpected record %+v", l)
+	}
+
+	if l := rl.ScopeLogs[1].LogRecords[0]; l.SeverityNumber != 13 || !*l.Attributes[0].Value.BoolValue || *l.Attributes[1].Value.DoubleValue != 0.5 {
+		t.Fatalf("unexpected record %+v", l)
+	}
+}
+
+func TestOTLPSinkBatch(t *testing.T) {
+	sizes := make(chan int, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e otlpExport
+		json.NewDecoder(r.Body).Decode(&e)
+		sizes <- len(e.ResourceLogs[0].ScopeLogs[0].LogRecords)
+	}))
+	defer srv.Close()
+
+	s := NewOTLPSink(OTLPOptions{Endpoint: srv.URL + "/", Interval: time.Hour, Batch: 2})
+	defer s.Close()
+
+	r := &Record{Time: time.Now(), Name: "x"}
+	s.Write(r)
+	s.Write(r)
+
+	select {
+	case n := <-sizes:
+		if n != 2 {
+			t.Fatalf("expected a batch of 2, got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a full batch to be exported")
+	}
+
+	for i := 0; i < 10; i++ {
+		s.Write(r)
+	}
+
+	if s.Dropped() == 0 {
+		t.Fatal("expected records beyond four batches to be dropped")
+	}
+}
@@ -0,0 +1,202 @@
+package debug
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A compiled pattern, see Enable and EnableOrdered. Names must also be
+// matched by the custom matcher when set, see SetMatcher.
+type pattern struct {
+	rules   []rule
+	ordered bool
+	custom  Matcher
+}
+
+// A single comma separated token of a pattern.
+type rule struct {
+	re     *regexp.Regexp
+	negate bool
+	level  int
+}
+
+// Compile `str` into a pattern. Tokens are separated by commas or
+// spaces, `*` matches anything, `?` a single character, `{a,b}` either
+// alternative while braces without a comma are literal, and a leading `-`
+// negates the token. A backslash escapes
+// the next character, such as `\*` for a literal star. A token ending in
+// the separator and `*`, such as "db:*", also matches its parent "db". A
+// token may end in "=N" to set the verbosity of the names it matches, see
+// V. Tokens also match the names of their Tagged namespaces, such as
+// "pool#7" for "pool". The caller must hold the lock.
+func compile(str string, ordered bool) *pattern {
+	p := &pattern{ordered: ordered}
+
+	str = strings.ToValidUTF8(str, "\ufffd")
+	for _, tok := range tokens(str) {
+		r := rule{}
+		if strings.HasPrefix(tok, "-") {
+			r.negate = true
+			tok = tok[1:]
+		}
+
+		if i := strings.LastIndex(tok, "="); i >= 0 {
+			if level, err := strconv.Atoi(tok[i+1:]); err == nil {
+				r.level = level
+				tok = tok[:i]
+			}
+		}
+
+		parent := ""
+		if sep := Separator(); strings.HasSuffix(tok, sep+"*") {
+			parent = "|" + globRegexp(strings.TrimSuffix(tok, sep+"*"))
+		}
+
+		r.re = regexp.MustCompile("(?s)^(" + globRegexp(tok) + parent + ")(#[0-9]+)?$")
+		p.rules = append(p.rules, r)
+	}
+
+	return p
+}
+
+// Split `str` into tokens at separators outside of braces and escapes.
+func tokens(str string) []string {
+	var list []string
+	start, depth := 0, 0
+
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case c == '\\':
+			i++
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case depth == 0 && isSeparator(rune(c)):
+			if i > start {
+				list = append(list, str[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	if start < len(str) {
+		list = append(list, str[start:])
+	}
+
+	return list
+}
+
+// Return the regular expression of the glob `tok`. Braces without a comma,
+// such as those of the route names of InstrumentMux, and unbalanced ones
+// are matched literally.
+func globRegexp(tok string) string {
+	alt := alternations(tok)
+
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(tok); i++ {
+		switch c := tok[i]; {
+		case c == '\\' && i+1 < len(tok):
+			i++
+			b.WriteString(regexp.QuoteMeta(tok[i : i+1]))
+		case c == '*':
+			b.WriteString(".*?")
+		case c == '?':
+			b.WriteString(".")
+		case c == '{' && alt[i]:
+			depth++
+			b.WriteString("(?:")
+		case c == '}' && alt[i]:
+			depth--
+			b.WriteString(")")
+		case c == ',' && depth > 0:
+			b.WriteString("|")
+		default:
+			b.WriteString(regexp.QuoteMeta(tok[i : i+1]))
+		}
+	}
+
+	return b.String()
+}
+
+// Return the offsets of the unescaped braces of `tok` delimiting groups of
+// alternatives, those directly holding a comma, or nil when they are not
+// balanced.
+func alternations(tok string) map[int]bool {
+	var open []int
+	var commas []bool
+	alt := map[int]bool{}
+
+	for i := 0; i < len(tok); i++ {
+		switch tok[i] {
+		case '\\':
+			i++
+		case '{':
+			open = append(open, i)
+			commas = append(commas, false)
+		case ',':
+			if len(commas) > 0 {
+				commas[len(commas)-1] = true
+			}
+		case '}':
+			if len(open) == 0 {
+				return nil
+			}
+
+			last := len(open) - 1
+			if commas[last] {
+				alt[open[last]] = true
+				alt[i] = true
+			}
+			open, commas = open[:last], commas[:last]
+		}
+	}
+
+	if len(open) > 0 {
+		return nil
+	}
+	return alt
+}
+
+// Check whether `name` is enabled by the pattern.
+func (p *pattern) match(name string) bool {
+	_, on := p.verbosity(name)
+	return on
+}
+
+// Return the verbosity of `name` and whether it is enabled. Unordered
+// patterns enable names matching any token unless a negated token matches,
+// with the highest verbosity of the matching tokens, while ordered ones
+// let the last matching token decide.
+func (p *pattern) verbosity(name string) (level int, on bool) {
+	if p.custom != nil {
+		if !p.custom.Match(name) {
+			return 0, false
+		}
+		on = true
+	}
+
+	for _, r := range p.rules {
+		if !r.re.MatchString(name) {
+			continue
+		}
+
+		switch {
+		case r.negate && !p.ordered:
+			return 0, false
+		case r.negate:
+			level, on = 0, false
+		case p.ordered || !on || r.level > level:
+			level, on = r.level, true
+		}
+	}
+
+	return level, on
+}
+
+// Check whether `c` separates pattern tokens.
+func isSeparator(c rune) bool {
+	return c == ',' || c == ' '
+}
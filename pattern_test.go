@@ -0,0 +1,144 @@
+package debug
+
+import "strings"
+import "testing"
+import "unicode/utf8"
+
+import "github.com/tj/go-debug/patterntest"
+
+func TestPatternMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		ordered bool
+		name    string
+		match   bool
+	}{
+		{"*", false, "anything", true},
+		{"mongo*,redis*", false, "redis:conn", true},
+		{"mongo*,redis*", false, "mysql", false},
+		{"mongo:*, redis", false, "redis", true},
+		{"*,-db:*", false, "db:pool", false},
+		{"-db:*,*", false, "db:pool", false},
+		{"-db:*", false, "http", false},
+		{"*,-db:*,db:pool", false, "db:pool", false},
+		{"*,-db:*,db:pool", true, "db:pool", true},
+		{"*,-db:*,db:pool", true, "db:conn", false},
+		{"*,-db:*,db:pool", true, "http", true},
+		{"db:pool,-db:*", true, "db:pool", false},
+		{"", false, "foo", false},
+		{"db:*", false, "db", true},
+		{"db:*", false, "dbx", false},
+		{"*,-db:*", false, "db", false},
+		{"d*:*", false, "db", true},
+		{"db:{pool,conn}", false, "db:conn", true},
+		{"db:{pool,conn}", false, "db:query", false},
+		{"db:{pool,conn},http", false, "http", true},
+		{"{db,cache}:*", false, "cache", true},
+		{"*,-db:{pool,c{onn,ache}}", false, "db:cache", false},
+		{"*,-db:{pool,c{onn,ache}}", false, "db:cxche", true},
+		{"db:?", false, "db:1", true},
+		{"db:?", false, "db:12", false},
+		{"db:\\*", false, "db:*", true},
+		{"db:\\*", false, "db:pool", false},
+		{"a\\,b", false, "a,b", true},
+		{"a\\?", false, "ab", false},
+		{"a{b", false, "a{b", true},
+		{"a}b", false, "a}b", true},
+	}
+
+	for _, c := range cases {
+		if compile(c.pattern, c.ordered).match(c.name) != c.match {
+			t.Errorf("expected %q (ordered %v) match of %q to be %v", c.pattern, c.ordered, c.name, c.match)
+		}
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	SetSeparator("/")
+	defer SetSeparator(":")
+
+	m.Lock()
+	p := compile("db/*", false)
+	m.Unlock()
+
+	if !p.match("db") || !p.match("db/pool") || p.match("db:pool") {
+		t.Fatalf("unexpected matching with / separator")
+	}
+
+	if name := Named("svc").Extend("http").Name(); name != "svc/http" {
+		t.Fatalf("unexpected extended name %q", name)
+	}
+
+	if a := Abbreviate("a/b/c/handler", 11); a != "…/c/handler" {
+		t.Fatalf("unexpected abbreviation %q", a)
+	}
+
+	SetSeparator("")
+	if s := Separator(); s != "/" {
+		t.Fatalf("unexpected separator %q after setting an empty one", s)
+	}
+}
+
+func TestSeparatorRecompiles(t *testing.T) {
+	Enable("db/*")
+	defer Disable()
+
+	if Named("db").Enabled() {
+		t.Fatalf("expected db to be disabled with the : separator")
+	}
+
+	SetSeparator("/")
+	defer SetSeparator(":")
+
+	if !Named("db").Enabled() {
+		t.Fatalf("expected the pattern to be recompiled with the / separator")
+	}
+}
+
+func TestPatternConformance(t *testing.T) {
+	patterntest.Run(t, func(pattern string, ordered bool, name string) bool {
+		return compile(pattern, ordered).match(name)
+	})
+}
+
+func FuzzPattern(f *testing.F) {
+	for _, cases := range [][]patterntest.Case{patterntest.Node, patterntest.Extensions} {
+		for _, c := range cases {
+			f.Add(c.Pattern, c.Name)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, name string) {
+		compile(pattern, false).match(name)
+		compile(pattern, true).match(name)
+
+		if compile("-"+quote(name), false).match(name) {
+			t.Fatalf("expected the negated name %q not to match", name)
+		}
+
+		// Names which cannot be written as a single plain token.
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, "= ") || !utf8.ValidString(name) {
+			return
+		}
+
+		if !compile(quote(name), false).match(name) {
+			t.Fatalf("expected the quoted name %q to match itself", name)
+		}
+
+		if !compile("*", false).match(name) {
+			t.Fatalf("expected * to match %q", name)
+		}
+	})
+}
+
+// Escape the special characters of `name`.
+func quote(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune(`\*?{},`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+// Package patterntest is a conformance suite for debug patterns, shared
+// by this module and forks extending the pattern language:
+//
+//	func TestPatterns(t *testing.T) {
+//		patterntest.Run(t, func(pattern string, ordered bool, name string) bool {
+//			return myCompile(pattern, ordered).Match(name)
+//		})
+//	}
+//
+// Cases assume the default ":" separator.
+package patterntest
+
+import "testing"
+
+// Case is the expected result of matching Name against Pattern, evaluated
+// in order when Ordered is set, see debug.EnableOrdered.
+type Case struct {
+	Pattern string
+	Ordered bool
+	Name    string
+	Match   bool
+}
+
+// Node lists cases behaving as in the node debug module.
+var Node = []Case{
+	{"*", false, "anything", true},
+	{"*", false, "", true},
+	{"foo", false, "foo", true},
+	{"foo", false, "foobar", false},
+	{"foo", false, "barfoo", false},
+	{"foo:*", false, "foo:bar", true},
+	{"foo:*", false, "foo:bar:baz", true},
+	{"foo:*", false, "bar:foo", false},
+	{"*:bar", false, "foo:bar", true},
+	{"*:bar", false, "foo:baz", false},
+	{"app:*:db", false, "app:users:db", true},
+	{"app:*:db", false, "app:users:http", false},
+	{"foo,bar", false, "bar", true},
+	{"foo bar", false, "bar", true},
+	{"foo, bar", false, "bar", true},
+	{"foo,,bar", false, "bar", true},
+	{"*,-foo", false, "foo", false},
+	{"*,-foo", false, "bar", true},
+	{"-foo,*", false, "foo", false},
+	{"*,-foo:*", false, "foo:bar", false},
+	{"-foo", false, "bar", false},
+	{"", false, "foo", false},
+	{"FOO", false, "foo", false},
+	{"foo.bar", false, "fooxbar", false},
+	{"foo+", false, "foo+", true},
+	{"(foo)", false, "(foo)", true},
+}
+
+// Extensions lists cases of the syntax added by go-debug: parents matched
+// by "name:*", ordered evaluation, verbosity suffixes, tagged namespaces,
+// single character wildcards, alternation and escapes.
+var Extensions = []Case{
+	{"foo:*", false, "foo", true},
+	{"foo:*", false, "foobar", false},
+	{"*,-foo:*", false, "foo", false},
+	{"*,-db:*,db:pool", false, "db:pool", false},
+	{"*,-db:*,db:pool", true, "db:pool", true},
+	{"*,-db:*,db:pool", true, "db:conn", false},
+	{"db:pool,-db:*", true, "db:pool", false},
+	{"raft=2", false, "raft", true},
+	{"raft=x", false, "raft=x", true},
+	{"-raft=2", false, "raft", false},
+	{"pool", false, "pool#7", true},
+	{"pool", false, "pool#x", false},
+	{"pool#7", false, "pool#7", true},
+	{"db:?", false, "db:1", true},
+	{"db:?", false, "db:", false},
+	{"db:?", false, "db:12", false},
+	{"db:{pool,conn}", false, "db:conn", true},
+	{"db:{pool,conn}", false, "db:query", false},
+	{"{db,cache}:*", false, "cache", true},
+	{"db:{pool,c{onn,ache}}", false, "db:cache", true},
+	{"db:{}", false, "db:{}", true},
+	{"http:GET:/users/{id}", false, "http:GET:/users/{id}", true},
+	{"db:{pool}", false, "db:pool", false},
+	{"db:{pool,conn},http", false, "http", true},
+	{"a{b", false, "a{b", true},
+	{"a}b", false, "a}b", true},
+	{`db:\*`, false, "db:*", true},
+	{`db:\*`, false, "db:pool", false},
+	{`a\?`, false, "a?", true},
+	{`a\?`, false, "ab", false},
+	{`a\,b`, false, "a,b", true},
+	{`a\{b,c}`, false, "a{b", true},
+	{`a\{b,c}`, false, "c}", true},
+	{`a\\`, false, `a\`, true},
+}
+
+// Run checks `match` against the Node and Extensions cases.
+func Run(t testing.TB, match func(pattern string, ordered bool, name string) bool) {
+	t.Helper()
+
+	for _, cases := range [][]Case{Node, Extensions} {
+		for _, c := range cases {
+			if got := match(c.Pattern, c.Ordered, c.Name); got != c.Match {
+				t.Errorf("expected %q (ordered %v) match of %q to be %v", c.Pattern, c.Ordered, c.Name, c.Match)
+			}
+		}
+	}
+}
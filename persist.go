@@ -0,0 +1,120 @@
+package debug
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	persistPath string
+	persistGen  int
+
+	// Held while saving, so that concurrent saves do not interleave.
+	persistMu    sync.Mutex
+	persistSaved int
+)
+
+// The content of a state file.
+type persistState struct {
+	Pattern string `json:"pattern"`
+	Ordered bool   `json:"ordered,omitempty"`
+}
+
+// Persist keeps the pattern in the state file `path` across restarts, so
+// that a service restarted during an investigation comes back with the
+// same debug configuration. The pattern saved by a previous run, if any,
+// replaces the current one, then every change of the pattern, such as
+// with Enable, Disable or EnableEnv, is saved before the call making it
+// returns. Call the returned function to stop saving.
+//
+// Custom matchers are not saved, and a pattern enabled with EnableFor is
+// saved as is, until it expires. Failures to save are written as warnings
+// of the "debug:persist" namespace.
+//
+// This function is thread-safe.
+func Persist(path string) (stop func(), err error) {
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	m.Lock()
+	if err == nil {
+		var s persistState
+		if err := json.Unmarshal(b, &s); err != nil {
+			m.Unlock()
+			return nil, err
+		}
+		setPattern(s.Pattern, s.Ordered)
+	}
+
+	persistPath = path
+	persistGen = generation
+	unlock()
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		if persistPath == path {
+			persistPath = ""
+		}
+	}, nil
+}
+
+// Return a function saving the pattern when it changed since it was last
+// saved, or nil. The lock must be held.
+func pendingSave() func() {
+	if persistPath == "" || persistGen == generation {
+		return nil
+	}
+
+	path, gen := persistPath, generation
+	state := persistState{Pattern: current, Ordered: ordered}
+	persistGen = gen
+
+	return func() {
+		persistMu.Lock()
+		defer persistMu.Unlock()
+
+		// A later change may have been saved first.
+		if gen < persistSaved {
+			return
+		}
+		persistSaved = gen
+
+		if err := saveState(path, state); err != nil {
+			newNamespace("debug:persist").emit(LevelWarn, "saving the pattern: "+err.Error(), nil)
+		}
+	}
+}
+
+// Write `state` to the file `path` atomically, replacing it once the new
+// content is on stable storage.
+func saveState(path string, state persistState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
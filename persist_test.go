@@ -0,0 +1,64 @@
+package debug
+
+import "bytes"
+import "os"
+import "path/filepath"
+import "testing"
+
+func TestPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.json")
+
+	defer Swap(bytes.NewBuffer(nil), "http")()
+
+	stop, err := Persist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Config().Pattern != "http" {
+		t.Fatalf("expected the pattern to be kept without a state file, got %q", Config().Pattern)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no state file before a change, got %v", err)
+	}
+
+	EnableOrdered("*,-db:*")
+	Disable("cache")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"pattern":"*,-db:*,-cache","ordered":true}`+"\n" {
+		t.Fatalf("unexpected state %q", b)
+	}
+
+	stop()
+	Enable("other")
+
+	// A restart comes back with the saved pattern.
+	stop, err = Persist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if c := Config(); c.Pattern != "*,-db:*,-cache" || !c.Ordered {
+		t.Fatalf("expected the saved pattern, got %q", c.Pattern)
+	}
+
+	if !Named("http").Enabled() || Named("db:conn").Enabled() {
+		t.Fatal("expected the saved pattern to be enabled")
+	}
+}
+
+func TestPersistMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.json")
+	os.WriteFile(path, []byte("nope"), 0644)
+
+	if _, err := Persist(path); err == nil {
+		t.Fatal("expected an error for a malformed state file")
+	}
+}
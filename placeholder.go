@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"io"
+	"regexp"
+)
+
+// Placeholder replaces high-cardinality values, such as ids and addresses,
+// in the records passed to a sink, see Placeholders.
+type Placeholder struct {
+	// Field, when set, designates a field whose whole value is replaced.
+	Field string
+
+	// Pattern, when Field is empty, matches the values replaced in the
+	// message and in the string values of fields.
+	Pattern *regexp.Regexp
+
+	// Text replacing the value.
+	Text string
+}
+
+// Built-in placeholders.
+var (
+	// UUIDs replaces UUIDs with "<uuid>".
+	UUIDs = Placeholder{
+		Pattern: regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+		Text:    "<uuid>",
+	}
+
+	// Addresses replaces IPv4 and IPv6 addresses, with their port if any,
+	// with "<addr>".
+	Addresses = Placeholder{
+		Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b|\[[0-9a-fA-F:.]+\](?::\d+)?|\b(?:[0-9a-fA-F]{1,4}:){2,7}[0-9a-fA-F]{1,4}\b`),
+		Text:    "<addr>",
+	}
+
+	// HexIDs replaces hexadecimal strings of 16 digits or more, such as
+	// trace ids and hashes, with "<hex>".
+	HexIDs = Placeholder{
+		Pattern: regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`),
+		Text:    "<hex>",
+	}
+
+	// Numbers replaces decimal numbers with "<n>", including those followed
+	// by a unit such as "42ms".
+	Numbers = Placeholder{
+		Pattern: regexp.MustCompile(`\b\d+(?:\.\d+)?`),
+		Text:    "<n>",
+	}
+)
+
+// Placeholders returns a Sink passing records to `s` with the values
+// designated by `placeholders` replaced, in order, for sinks feeding
+// metrics or systems grouping messages by template, where ids and
+// addresses would explode the number of distinct values. The writer and
+// other sinks still receive the full values:
+//
+//	debug.AddSink(debug.Placeholders(metrics, debug.UUIDs, debug.Addresses,
+//		debug.Placeholder{Field: "user", Text: "<user>"}))
+//
+// The returned sink implements io.Closer, closing `s` if it does.
+func Placeholders(s Sink, placeholders ...Placeholder) Sink {
+	return &placeholderSink{s, placeholders}
+}
+
+// Sink replacing values, see Placeholders.
+type placeholderSink struct {
+	sink         Sink
+	placeholders []Placeholder
+}
+
+// Write implements Sink.
+func (p *placeholderSink) Write(r *Record) error {
+	c := *r
+	c.Fields = append([]KV(nil), r.Fields...)
+
+	for _, ph := range p.placeholders {
+		if ph.Field != "" {
+			for i, f := range c.Fields {
+				if f.Key == ph.Field {
+					c.Fields[i].Value = ph.Text
+				}
+			}
+			continue
+		}
+
+		if ph.Pattern == nil {
+			continue
+		}
+
+		c.Message = ph.Pattern.ReplaceAllLiteralString(c.Message, ph.Text)
+		for i, f := range c.Fields {
+			if s, ok := f.Value.(string); ok {
+				c.Fields[i].Value = ph.Pattern.ReplaceAllLiteralString(s, ph.Text)
+			}
+		}
+	}
+
+	return p.sink.Write(&c)
+}
+
+// Close implements io.Closer.
+func (p *placeholderSink) Close() error {
+	if c, ok := p.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestPlaceholders(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "api")()
+
+	s := &recordSink{}
+	defer AddSink(Placeholders(s, UUIDs, Addresses, HexIDs, Placeholder{Field: "user", Text: "<user>"}, Numbers))()
+
+	log := Named("api")
+	log.log(0, LevelDebug, []KV{{"user", "tobi"}, {"peer", "[::1]:8080"}, {"n", 3}},
+		"request 6ba7b810-9dad-11d1-80b4-00c04fd430c8 from 10.0.0.12:5432 trace 4bf92f3577b34da6a3ce929d0e0e4736 took %dms", 42)
+
+	r := s.records[0]
+	if r.Message != "request <uuid> from <addr> trace <hex> took <n>ms" {
+		t.Fatalf("unexpected message %q", r.Message)
+	}
+
+	if r.Fields[0].Value != "<user>" || r.Fields[1].Value != "<addr>" || r.Fields[2].Value != 3 {
+		t.Fatalf("unexpected fields %v", r.Fields)
+	}
+
+	assertContains(t, buf.String(), "request 6ba7b810-9dad-11d1-80b4-00c04fd430c8 from 10.0.0.12:5432")
+	assertContains(t, buf.String(), "user=tobi")
+}
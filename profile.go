@@ -0,0 +1,21 @@
+package debug
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Profile calls `fn`, labelling its goroutine with "debug_ns=<name>" for
+// CPU and goroutine profiles when namespace `name` is enabled for `ctx`,
+// so profiles can be filtered to the code being debugged, for example
+// with `go tool pprof -tagfocus debug_ns=db:query`. Goroutines started by
+// `fn` inherit the label. When the namespace is disabled `fn` is called
+// with `ctx` unchanged.
+func Profile(ctx context.Context, name string, fn func(context.Context)) {
+	if !Named(name).EnabledContext(ctx) {
+		fn(ctx)
+		return
+	}
+
+	pprof.Do(ctx, pprof.Labels("debug_ns", name), fn)
+}
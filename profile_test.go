@@ -0,0 +1,22 @@
+package debug
+
+import "context"
+import "io"
+import "runtime/pprof"
+import "testing"
+
+func TestProfile(t *testing.T) {
+	defer Swap(io.Discard, "profile:on")()
+
+	Profile(context.Background(), "profile:on", func(ctx context.Context) {
+		if v, ok := pprof.Label(ctx, "debug_ns"); !ok || v != "profile:on" {
+			t.Fatalf("expected the namespace label, got %q", v)
+		}
+	})
+
+	Profile(context.Background(), "profile:off", func(ctx context.Context) {
+		if _, ok := pprof.Label(ctx, "debug_ns"); ok {
+			t.Fatalf("expected no label for a disabled namespace")
+		}
+	})
+}
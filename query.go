@@ -0,0 +1,321 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query selects records and projects their columns, see ParseQuery.
+type Query struct {
+	columns []string
+	conds   []queryCond
+}
+
+// A condition of a query.
+type queryCond struct {
+	column string
+	op     string
+	value  string
+	re     *regexp.Regexp
+	pat    *pattern
+	time   time.Time
+	level  Level
+}
+
+// Built-in columns, in the order of "select *".
+var queryColumns = []string{"time", "name", "level", "message"}
+
+// ParseQuery parses a query over records in a small SQL-like language:
+//
+//	select time, name, message where name ~ 'db:*' and level >= warn and user = 'tobi'
+//
+// Columns are the built-in time, name, level and message, or the name of
+// a field; "*" selects the built-in ones. Conditions joined by "and"
+// compare a column with =, !=, <, <=, > or >=, or match a glob with ~, in
+// the syntax of patterns for names and of a single token otherwise. Times
+// are given in RFC 3339 format and levels by name. Fields compare as
+// numbers when both sides are numbers, and as strings otherwise; records
+// without the field do not match. Values containing spaces or operators
+// are quoted with single quotes, doubled within them. Keywords are case
+// insensitive, and "ns" and "msg" are aliases of name and message.
+func ParseQuery(s string) (*Query, error) {
+	toks, err := queryTokens(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(toks) == 0 || !strings.EqualFold(toks[0], "select") {
+		return nil, fmt.Errorf("debug: query must start with select")
+	}
+	toks = toks[1:]
+
+	q := &Query{}
+	for len(toks) > 0 && !strings.EqualFold(toks[0], "where") {
+		if toks[0] == "*" {
+			q.columns = append(q.columns, queryColumns...)
+		} else {
+			q.columns = append(q.columns, queryColumn(toks[0]))
+		}
+		toks = toks[1:]
+
+		if len(toks) > 0 && toks[0] == "," {
+			toks = toks[1:]
+		}
+	}
+
+	if len(q.columns) == 0 {
+		return nil, fmt.Errorf("debug: query selects no columns")
+	}
+
+	if len(toks) == 0 {
+		return q, nil
+	}
+	toks = toks[1:]
+
+	for {
+		if len(toks) < 3 {
+			return nil, fmt.Errorf("debug: incomplete condition in query")
+		}
+
+		c, err := parseCond(queryColumn(toks[0]), toks[1], toks[2])
+		if err != nil {
+			return nil, err
+		}
+		q.conds = append(q.conds, c)
+		toks = toks[3:]
+
+		if len(toks) == 0 {
+			return q, nil
+		}
+
+		if !strings.EqualFold(toks[0], "and") {
+			return nil, fmt.Errorf("debug: unexpected %q in query", toks[0])
+		}
+		toks = toks[1:]
+	}
+}
+
+// Columns returns the names of the selected columns.
+func (q *Query) Columns() []string {
+	return q.columns
+}
+
+// Match reports whether `r` meets the conditions of the query.
+func (q *Query) Match(r *Record) bool {
+	for _, c := range q.conds {
+		if !c.match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Project returns the values of the selected columns of `r`, nil for
+// fields it does not have.
+func (q *Query) Project(r *Record) []interface{} {
+	row := make([]interface{}, len(q.columns))
+	for i, col := range q.columns {
+		row[i], _ = columnValue(r, col)
+	}
+	return row
+}
+
+// Run reads the records of `rd`, such as a Reader or Merger, until io.EOF,
+// calling `fn` with the projection of those matching the query. Records are
+// streamed rather than loaded at once, so recordings of any size can be
+// queried. It stops at the first error of `rd` or `fn`.
+func (q *Query) Run(rd interface{ Read() (*Record, error) }, fn func(row []interface{}) error) error {
+	for {
+		r, err := rd.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !q.Match(r) {
+			continue
+		}
+
+		if err := fn(q.Project(r)); err != nil {
+			return err
+		}
+	}
+}
+
+// Return the canonical name of column `name`.
+func queryColumn(name string) string {
+	switch strings.ToLower(name) {
+	case "time", "level":
+		return strings.ToLower(name)
+	case "name", "ns":
+		return "name"
+	case "message", "msg":
+		return "message"
+	}
+	return name
+}
+
+// Return the value of column `col` of `r`, and whether it has one.
+func columnValue(r *Record, col string) (interface{}, bool) {
+	switch col {
+	case "time":
+		return r.Time, true
+	case "name":
+		return r.Name, true
+	case "level":
+		return r.Level, true
+	case "message":
+		return r.Message, true
+	}
+
+	for _, f := range r.Fields {
+		if f.Key == col {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Parse the condition comparing `column` with `value` by `op`.
+func parseCond(column, op, value string) (queryCond, error) {
+	c := queryCond{column: column, op: op, value: value}
+
+	switch op {
+	case "=", "!=", "<", "<=", ">", ">=":
+	case "~":
+		if column == "name" {
+			m.Lock()
+			c.pat = compile(value, false)
+			m.Unlock()
+			return c, nil
+		}
+
+		re, err := regexp.Compile("(?s)^(?:" + globRegexp(value) + ")$")
+		if err != nil {
+			return c, fmt.Errorf("debug: invalid glob %q in query: %w", value, err)
+		}
+		c.re = re
+		return c, nil
+	default:
+		return c, fmt.Errorf("debug: unknown operator %q in query", op)
+	}
+
+	var err error
+	switch column {
+	case "time":
+		c.time, err = time.Parse(time.RFC3339Nano, value)
+	case "level":
+		c.level, err = ParseLevel(value)
+	}
+
+	return c, err
+}
+
+// Check whether `r` meets the condition.
+func (c *queryCond) match(r *Record) bool {
+	v, ok := columnValue(r, c.column)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case c.pat != nil:
+		return c.pat.match(r.Name)
+	case c.re != nil:
+		return c.re.MatchString(fmt.Sprint(v))
+	case c.column == "time":
+		return compare(c.op, r.Time.Compare(c.time))
+	case c.column == "level":
+		return compare(c.op, int(r.Level)-int(c.level))
+	}
+
+	s := fmt.Sprint(v)
+	a, errA := strconv.ParseFloat(s, 64)
+	b, errB := strconv.ParseFloat(c.value, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			return compare(c.op, -1)
+		case a > b:
+			return compare(c.op, 1)
+		}
+		return compare(c.op, 0)
+	}
+
+	return compare(c.op, strings.Compare(s, c.value))
+}
+
+// Check whether the result `cmp` of a comparison, negative, zero or
+// positive, satisfies `op`.
+func compare(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// Split query `s` into words, quoted strings, operators and commas.
+func queryTokens(s string) ([]string, error) {
+	var toks []string
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == ',':
+			toks = append(toks, ",")
+			i++
+		case c == '\'':
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("debug: unterminated string in query")
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						i++
+					} else {
+						break
+					}
+				}
+				b.WriteByte(s[i])
+			}
+			toks = append(toks, b.String())
+			i++
+		case strings.IndexByte("=!<>~", c) >= 0:
+			j := i + 1
+			if j < len(s) && s[j] == '=' && c != '=' && c != '~' {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && strings.IndexByte(" \t\n,'=!<>~", s[j]) < 0 {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+
+	return toks, nil
+}
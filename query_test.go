@@ -0,0 +1,108 @@
+package debug
+
+import "bytes"
+import "testing"
+import "time"
+
+func TestQuery(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, EncodingJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	records := []*Record{
+		{Time: start, Name: "db:pool", Message: "checkout", Fields: []KV{{"user", "tobi"}, {"ms", 3}}},
+		{Time: start.Add(time.Second), Name: "db:query", Level: LevelWarn, Message: "slow query", Fields: []KV{{"user", "tobi"}, {"ms", 120}}},
+		{Time: start.Add(2 * time.Second), Name: "http", Level: LevelError, Message: "failed, retrying", Fields: []KV{{"user", "loki"}}},
+		{Time: start.Add(3 * time.Second), Name: "db:query", Message: "fast query", Fields: []KV{{"user", "loki"}, {"ms", 9}}},
+	}
+	for _, r := range records {
+		rec.Write(r)
+	}
+
+	cases := []struct {
+		query string
+		rows  []string
+	}{
+		{"select msg", []string{"checkout", "slow query", "failed, retrying", "fast query"}},
+		{"SELECT message WHERE ns ~ 'db:*'", []string{"checkout", "slow query", "fast query"}},
+		{"select message where name ~ '*,-db:pool'", []string{"slow query", "failed, retrying", "fast query"}},
+		{"select message where level >= warn", []string{"slow query", "failed, retrying"}},
+		{"select message where ms > 5 and user = tobi", []string{"slow query"}},
+		{"select message where ms <= 9", []string{"checkout", "fast query"}},
+		{"select message where user != 'tobi'", []string{"failed, retrying", "fast query"}},
+		{"select message where message ~ '*, retry*'", []string{"failed, retrying"}},
+		{"select message where time >= '2024-01-02T15:04:06Z' and time < '2024-01-02T15:04:08Z'", []string{"slow query", "failed, retrying"}},
+	}
+
+	for _, c := range cases {
+		q, err := ParseQuery(c.query)
+		if err != nil {
+			t.Fatalf("%s: %s", c.query, err)
+		}
+
+		rd, err := NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rows []string
+		err = q.Run(rd, func(row []interface{}) error {
+			rows = append(rows, row[0].(string))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", c.query, err)
+		}
+
+		if len(rows) != len(c.rows) {
+			t.Errorf("%s: expected %q, got %q", c.query, c.rows, rows)
+			continue
+		}
+		for i := range rows {
+			if rows[i] != c.rows[i] {
+				t.Errorf("%s: expected %q, got %q", c.query, c.rows, rows)
+				break
+			}
+		}
+	}
+}
+
+func TestQueryProject(t *testing.T) {
+	q, err := ParseQuery("select *, user, missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	row := q.Project(&Record{Time: now, Name: "db", Level: LevelInfo, Message: "hi", Fields: []KV{{"user", "tobi"}}})
+	if len(row) != 6 || row[0] != now || row[1] != "db" || row[2] != LevelInfo || row[3] != "hi" || row[4] != "tobi" || row[5] != nil {
+		t.Fatalf("unexpected row %v", row)
+	}
+
+	if cols := q.Columns(); len(cols) != 6 || cols[4] != "user" {
+		t.Fatalf("unexpected columns %q", cols)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"message",
+		"select",
+		"select message where",
+		"select message where level >=",
+		"select message where level >= loud",
+		"select message where time < yesterday",
+		"select message where user ! tobi",
+		"select message where user = 'tobi",
+		"select message where user = tobi or user = loki",
+		"select message where user ~ \xff",
+	} {
+		if _, err := ParseQuery(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package debug
+
+// The race detector allocates, see TestAppendAllocs.
+const raceEnabled = true
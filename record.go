@@ -0,0 +1,207 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// KV is a key/value field attached to a Record.
+type KV struct {
+	Key   string
+	Value interface{}
+}
+
+// Record is a single line of debug output. Records are reused once they
+// have been written, so formatters and sinks must not retain them: use
+// Clone to keep a copy.
+type Record struct {
+	Time    time.Time
+	Name    string
+	Level   Level
+	Message string
+	Fields  []KV
+
+	// ID identifies the namespace within the process, see Namespace.ID.
+	ID uint32
+
+	// Global is the time since the previous line, and Delta the time
+	// since the previous line of the same namespace.
+	Global time.Duration
+	Delta  time.Duration
+
+	// Elapsed is the time since the start of the process on the monotonic
+	// clock, zero unless enabled with SetTimeMode.
+	Elapsed time.Duration
+
+	// Fingerprint is a stable hash of the namespace and the format string
+	// of the record, so that aggregators can group records whose messages
+	// only differ by their arguments. It is zero for records written
+	// without a format string, such as with Bytes.
+	Fingerprint uint64
+
+	// Counters of the namespace, for the records dropped by sinks.
+	stats *counters
+}
+
+// Clone returns a copy of `r` which shares no state with it.
+func (r *Record) Clone() *Record {
+	c := *r
+	c.Message = strings.Clone(r.Message)
+	c.Fields = append([]KV(nil), r.Fields...)
+	return &c
+}
+
+// Return the fingerprint of format string `format` in namespace `name`, an
+// FNV-1a hash of both, or zero without a format.
+func fingerprint(name, format string) uint64 {
+	if format == "" {
+		return 0
+	}
+
+	h := uint64(14695981039346656037)
+	for _, s := range [...]string{name, "\x00", format} {
+		for i := 0; i < len(s); i++ {
+			h ^= uint64(s[i])
+			h *= 1099511628211
+		}
+	}
+
+	return h
+}
+
+// Level is the severity of a record. Lines written by debug functions and
+// Printf are at LevelDebug.
+type Level int
+
+// Levels.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lower-case name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// Badge returns the compact badge of the level, such as "WRN".
+func (l Level) Badge() string {
+	switch l {
+	case LevelDebug:
+		return "DBG"
+	case LevelInfo:
+		return "INF"
+	case LevelWarn:
+		return "WRN"
+	case LevelError:
+		return "ERR"
+	default:
+		return "???"
+	}
+}
+
+// Color returns the ANSI color code of the badge.
+func (l Level) Color() string {
+	switch l {
+	case LevelInfo:
+		return "36"
+	case LevelWarn:
+		return "33"
+	case LevelError:
+		return "31"
+	default:
+		return "90"
+	}
+}
+
+// Syslog returns the syslog priority of the level, which is also the
+// PRIORITY used by journald.
+func (l Level) Syslog() int {
+	switch l {
+	case LevelInfo:
+		return 6
+	case LevelWarn:
+		return 4
+	case LevelError:
+		return 3
+	default:
+		return 7
+	}
+}
+
+// Severity returns the name of the level as used by cloud logging
+// services, such as "WARNING".
+func (l Level) Severity() string {
+	switch l {
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "DEBUG"
+	}
+}
+
+// ParseLevel parses the name or badge of a level, ignoring case.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug", "dbg":
+		return LevelDebug, nil
+	case "info", "inf":
+		return LevelInfo, nil
+	case "warn", "warning", "wrn":
+		return LevelWarn, nil
+	case "error", "err":
+		return LevelError, nil
+	default:
+		return LevelDebug, fmt.Errorf("debug: unknown level %q", s)
+	}
+}
+
+// Return the level given by a prefix of `msg`, see SetLevelPrefixes, and
+// the message without it. Messages without a prefix are at LevelDebug.
+func levelPrefix(msg string) (Level, string) {
+	var word, rest string
+
+	switch {
+	case strings.HasPrefix(msg, "!"):
+		word, rest, _ = strings.Cut(msg[1:], " ")
+	case strings.HasPrefix(msg, "["):
+		var ok bool
+		if word, rest, ok = strings.Cut(msg[1:], "]"); !ok {
+			return LevelDebug, msg
+		}
+	default:
+		var ok bool
+		if word, rest, ok = strings.Cut(msg, ":"); !ok {
+			return LevelDebug, msg
+		}
+	}
+
+	if len(word) > len("warning") {
+		return LevelDebug, msg
+	}
+
+	level, err := ParseLevel(word)
+	if err != nil || level == LevelDebug {
+		return LevelDebug, msg
+	}
+
+	return level, strings.TrimLeft(rest, " ")
+}
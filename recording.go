@@ -0,0 +1,171 @@
+package debug
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Recordings are self-describing files of records, written by a Recorder
+// and read back by a Reader. A recording starts with the line "go-debug 1"
+// and a JSON Hello line naming the process and the encoding, followed by
+// one frame per record: its length as a uvarint, then the record encoded
+// with JSONFormat or MsgpackFormat.
+const recordingMagic = "go-debug 1\n"
+
+// Largest frame accepted by a Reader.
+const maxFrame = 64 << 20
+
+// ErrRecording is returned when reading a malformed recording.
+var ErrRecording = errors.New("debug: malformed recording")
+
+// Recorder is a Sink saving records as a recording, for later analysis with
+// a Reader or the debugview command.
+type Recorder struct {
+	m      sync.Mutex
+	w      io.Writer
+	format Formatter
+	buf    []byte
+}
+
+// NewRecorder writes the header of a recording of encoding `enc` to `w`,
+// and returns a Recorder appending records to it. An empty encoding is
+// treated as EncodingJSON. Add it with AddSink.
+func NewRecorder(w io.Writer, enc Encoding) (*Recorder, error) {
+	if enc == "" {
+		enc = EncodingJSON
+	}
+
+	hello, err := json.Marshal(newHello(enc))
+	if err != nil {
+		return nil, err
+	}
+
+	b := append([]byte(recordingMagic), hello...)
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		w:      w,
+		format: enc.Formatter(),
+		buf:    make([]byte, binary.MaxVarintLen64),
+	}, nil
+}
+
+// Write implements Sink.
+func (rec *Recorder) Write(r *Record) error {
+	rec.m.Lock()
+	defer rec.m.Unlock()
+
+	// Room is left for the length, which is only known once formatted.
+	rec.buf = rec.format.Format(rec.buf[:binary.MaxVarintLen64], r, "")
+
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(rec.buf)-binary.MaxVarintLen64))
+	start := binary.MaxVarintLen64 - n
+	copy(rec.buf[start:], size[:n])
+
+	_, err := rec.w.Write(rec.buf[start:])
+	return err
+}
+
+// Reader iterates the records of a recording, or of a plain stream of
+// JSONFormat lines or MsgpackFormat values such as the output of SetFormat.
+type Reader struct {
+	r      *bufio.Reader
+	hello  Hello
+	framed bool
+	dec    *Decoder
+	buf    []byte
+	frame  bytes.Reader
+}
+
+// NewReader returns a Reader of the records in `r`, reading the header of
+// recordings. Plain streams are detected from their first byte.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	rd := &Reader{r: br}
+
+	head, err := br.Peek(len(recordingMagic))
+	if err == nil && string(head) == recordingMagic {
+		br.Discard(len(recordingMagic))
+
+		line, err := readLine(br, maxFrame)
+		if err != nil {
+			return nil, ErrRecording
+		}
+
+		if err := json.Unmarshal(line, &rd.hello); err != nil {
+			return nil, ErrRecording
+		}
+
+		if rd.hello.Encoding == "" {
+			rd.hello.Encoding = EncodingJSON
+		}
+
+		rd.framed = true
+		rd.dec = NewDecoder(&rd.frame, rd.hello.Encoding)
+		return rd, nil
+	}
+
+	rd.hello.Encoding = EncodingJSON
+	if c, err := br.Peek(1); err == nil && isMsgpackMap(c[0]) {
+		rd.hello.Encoding = EncodingMsgpack
+	}
+
+	rd.dec = NewDecoder(br, rd.hello.Encoding)
+	return rd, nil
+}
+
+// Hello returns the process which wrote the recording. Only the encoding
+// is set for plain streams.
+func (rd *Reader) Hello() Hello {
+	return rd.hello
+}
+
+// Read returns the next record, or io.EOF at the end of the stream.
+func (rd *Reader) Read() (*Record, error) {
+	if !rd.framed {
+		return rd.dec.Decode()
+	}
+
+	n, err := binary.ReadUvarint(rd.r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil || n > maxFrame {
+		return nil, ErrRecording
+	}
+
+	if uint64(cap(rd.buf)) < n {
+		rd.buf = make([]byte, n)
+	}
+	rd.buf = rd.buf[:n]
+
+	if _, err := io.ReadFull(rd.r, rd.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	rd.frame.Reset(rd.buf)
+	rd.dec.r.Reset(&rd.frame)
+
+	r, err := rd.dec.Decode()
+	if err != nil {
+		return nil, ErrRecording
+	}
+
+	return r, nil
+}
+
+// Check whether `c` starts a MessagePack map.
+func isMsgpackMap(c byte) bool {
+	return c&0xf0 == 0x80 || c == 0xde || c == 0xdf
+}
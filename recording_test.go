@@ -0,0 +1,82 @@
+package debug
+
+import "bytes"
+import "io"
+import "testing"
+import "time"
+
+func TestRecording(t *testing.T) {
+	for _, enc := range []Encoding{EncodingJSON, EncodingMsgpack, EncodingCompactJSON, EncodingCompactMsgpack} {
+		var buf bytes.Buffer
+
+		rec, err := NewRecorder(&buf, enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Unix(1414000000, 0)
+		rec.Write(&Record{Time: now, Name: "db", ID: 1, Level: LevelWarn, Message: "slow\nquery", Fields: []KV{{"ms", 120}}})
+		rec.Write(&Record{Time: now, Name: "http", ID: 2, Message: "GET /"})
+
+		rd, err := NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if h := rd.Hello(); h.Encoding != enc || h.Pid == 0 {
+			t.Fatalf("unexpected hello %+v", h)
+		}
+
+		r, err := rd.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Name != "db" || r.Level != LevelWarn || r.Message != "slow\nquery" || !r.Time.Equal(now) || len(r.Fields) != 1 || r.Fields[0].Key != "ms" {
+			t.Fatalf("unexpected record %+v", r)
+		}
+
+		if r, err = rd.Read(); err != nil || r.Name != "http" {
+			t.Fatalf("unexpected second record %+v, %v", r, err)
+		}
+
+		if _, err := rd.Read(); err != io.EOF {
+			t.Fatalf("expected EOF, got %v", err)
+		}
+	}
+}
+
+func TestReaderPlain(t *testing.T) {
+	for _, enc := range []Encoding{EncodingJSON, EncodingMsgpack} {
+		b := enc.Formatter().Format(nil, &Record{Name: "db", Message: "hello"}, "")
+
+		rd, err := NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rd.Hello().Encoding != enc {
+			t.Fatalf("expected %s, got %s", enc, rd.Hello().Encoding)
+		}
+
+		if r, err := rd.Read(); err != nil || r.Message != "hello" {
+			t.Fatalf("unexpected record %+v, %v", r, err)
+		}
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	var buf bytes.Buffer
+
+	rec, _ := NewRecorder(&buf, EncodingJSON)
+	rec.Write(&Record{Name: "db", Message: "hello"})
+
+	rd, err := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rd.Read(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package debug
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Whether a record is being written, see reentrant.
+var writing atomic.Bool
+
+// Function writing records with the lock held.
+var writeFunc = selfPackage + ".(*Namespace).write"
+
+// Check whether the calling goroutine is writing a record, that is whether
+// a writer, formatter or sink is logging with the lock held, which would
+// deadlock or recurse forever. Such records are dropped. Stacks are only
+// inspected while a record is being written and the lock cannot be taken,
+// as the goroutine writing holds it: other goroutines would then wait for
+// the lock anyway.
+func reentrant() bool {
+	if !writing.Load() {
+		return false
+	}
+
+	if m.TryLock() {
+		m.Unlock()
+		return false
+	}
+
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+
+	for {
+		frame, more := frames.Next()
+		if frame.Function == writeFunc {
+			return true
+		}
+
+		if !more {
+			return false
+		}
+	}
+}
+
+// Take the lock, unless the calling goroutine holds it to write a record,
+// such as a sink calling Named, in which case the state it guards is
+// already safe to use. It returns whether the lock was taken, to be
+// released with unlock.
+func lockUnlessWriting() bool {
+	if m.TryLock() {
+		return true
+	}
+
+	if reentrant() {
+		return false
+	}
+
+	m.Lock()
+	return true
+}
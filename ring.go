@@ -0,0 +1,304 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Rings are files mapped in memory by a RingSink and any number of
+// RingReaders, possibly in other processes. A ring starts with a header of
+// 64 bytes: the magic "go-debug ring 1\n", the capacity of the data as a
+// little endian uint64, then the cursor, the total number of bytes ever
+// reserved by the writer. The data follows, with one entry per record,
+// aligned on 8 bytes: a little endian uint64 of the length of the record
+// in the high half and of a tag in the low half, then the record encoded
+// with MsgpackFormat. The tag, derived from the position of the entry, is
+// written last, marking the entry as complete, so that readers never see
+// partial records.
+const ringMagic = "go-debug ring 1\n"
+
+// Size of the header of a ring, and offsets of its fields.
+const (
+	ringHeader   = 64
+	ringCapacity = 16
+	ringCursor   = 24
+)
+
+// ErrRingUnsupported is returned by NewRingSink and OpenRing on platforms
+// without shared memory maps.
+var ErrRingUnsupported = errors.New("debug: rings are not supported on this platform")
+
+// ErrRing is returned when opening a file which is not a ring.
+var ErrRing = errors.New("debug: malformed ring")
+
+// RingSink is a Sink writing records to a ring file mapped in memory, for
+// debugging hot paths where even buffered writes to a file perturb timing.
+// Writers reserve room with a single atomic addition and copy the record,
+// never blocking nor making system calls, and the oldest records are
+// overwritten once the ring is full. The ring is read while it is written,
+// by OpenRing or the debugring command:
+//
+//	ring, err := debug.NewRingSink("/dev/shm/app.ring", 64<<20)
+//	...
+//	defer ring.Close()
+//	debug.AddSink(ring)
+//
+// and:
+//
+//	$ debugring -f /dev/shm/app.ring
+//
+// Rings are only supported on Unix systems.
+type RingSink struct {
+	f       *os.File
+	mem     []byte
+	data    []byte
+	cursor  *uint64
+	bufs    sync.Pool
+	dropped atomic.Uint64
+}
+
+// NewRingSink creates the ring file `path`, truncating it, with room for
+// `size` bytes of records, rounded up to a multiple of 8 and to at least
+// 4KiB.
+func NewRingSink(path string, size int) (*RingSink, error) {
+	size = max((size+7)&^7, 4096)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	var head [ringHeader]byte
+	copy(head[:], ringMagic)
+	binary.LittleEndian.PutUint64(head[ringCapacity:], uint64(size))
+
+	if _, err := f.Write(head[:]); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Truncate(int64(ringHeader + size)); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	mem, err := mapRing(f, ringHeader+size, true)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &RingSink{
+		f:      f,
+		mem:    mem,
+		data:   mem[ringHeader:],
+		cursor: (*uint64)(unsafe.Pointer(&mem[ringCursor])),
+	}, nil
+}
+
+// Write implements Sink. Records larger than the ring are dropped.
+func (s *RingSink) Write(r *Record) error {
+	p, _ := s.bufs.Get().(*[]byte)
+	if p == nil {
+		p = new([]byte)
+	}
+	defer s.bufs.Put(p)
+
+	*p = MsgpackFormat.Format((*p)[:0], r, "")
+	b := *p
+
+	n := uint64(8 + (len(b)+7)&^7)
+	if n > uint64(len(s.data)) {
+		s.dropped.Add(1)
+		return nil
+	}
+
+	pos := atomic.AddUint64(s.cursor, n) - n
+	ringCopy(s.data, pos+8, b)
+	atomic.StoreUint64(ringWord(s.data, pos), uint64(len(b))<<32|ringTag(pos))
+	return nil
+}
+
+// Dropped returns the number of records dropped for being larger than the
+// ring.
+func (s *RingSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unmaps and closes the ring file, which keeps its records for
+// readers. The sink must not be written to anymore.
+func (s *RingSink) Close() error {
+	err := unmapRing(s.mem)
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// RingReader reads the records of a ring while it is written.
+type RingReader struct {
+	f        *os.File
+	mem      []byte
+	data     []byte
+	cursor   *uint64
+	pos      uint64
+	overruns int
+	buf      []byte
+	frame    bytes.Reader
+	dec      *Decoder
+}
+
+// OpenRing opens the ring file `path` for reading, starting with its oldest
+// record.
+func OpenRing(path string) (*RingReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var head [ringHeader]byte
+	if _, err := io.ReadFull(f, head[:]); err != nil || string(head[:len(ringMagic)]) != ringMagic {
+		f.Close()
+		return nil, ErrRing
+	}
+
+	size := binary.LittleEndian.Uint64(head[ringCapacity:])
+	if size == 0 || size%8 != 0 || size > 1<<40 {
+		f.Close()
+		return nil, ErrRing
+	}
+
+	// Reading the mapping past the end of a truncated file would fault.
+	if info, err := f.Stat(); err != nil || info.Size() < ringHeader+int64(size) {
+		f.Close()
+		return nil, ErrRing
+	}
+
+	mem, err := mapRing(f, ringHeader+int(size), false)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	rd := &RingReader{
+		f:      f,
+		mem:    mem,
+		data:   mem[ringHeader:],
+		cursor: (*uint64)(unsafe.Pointer(&mem[ringCursor])),
+	}
+	rd.dec = NewDecoder(&rd.frame, EncodingMsgpack)
+	rd.resync()
+	return rd, nil
+}
+
+// Read returns the next record, or io.EOF when every complete record has
+// been read. Reading again later returns the records written meanwhile,
+// so that rings can be followed by polling.
+func (rd *RingReader) Read() (*Record, error) {
+	for {
+		cursor := atomic.LoadUint64(rd.cursor)
+		if cursor-rd.pos > uint64(len(rd.data)) {
+			rd.overruns++
+			rd.resync()
+			continue
+		}
+
+		if rd.pos == cursor {
+			return nil, io.EOF
+		}
+
+		word := atomic.LoadUint64(ringWord(rd.data, rd.pos))
+		if uint32(word) != uint32(ringTag(rd.pos)) {
+			return nil, io.EOF
+		}
+
+		size := word >> 32
+		if size > uint64(len(rd.data))-8 {
+			return nil, ErrRing
+		}
+
+		rd.buf = ringRead(rd.data, rd.pos+8, rd.buf[:0], size)
+
+		// The entry may have been overwritten while it was copied.
+		if atomic.LoadUint64(rd.cursor)-rd.pos > uint64(len(rd.data)) {
+			continue
+		}
+		rd.pos += 8 + (size+7)&^7
+
+		rd.frame.Reset(rd.buf)
+		rd.dec.r.Reset(&rd.frame)
+
+		r, err := rd.dec.Decode()
+		if err != nil {
+			return nil, ErrRing
+		}
+		return r, nil
+	}
+}
+
+// Overruns returns the number of times the writer overwrote records before
+// they were read, losing them.
+func (rd *RingReader) Overruns() int {
+	return rd.overruns
+}
+
+// Close unmaps and closes the ring file.
+func (rd *RingReader) Close() error {
+	err := unmapRing(rd.mem)
+	if cerr := rd.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Move to the oldest complete entry still in the ring, or to the cursor,
+// scanning for an entry whose tag matches its position.
+func (rd *RingReader) resync() {
+	cursor := atomic.LoadUint64(rd.cursor)
+
+	pos := uint64(0)
+	if cursor > uint64(len(rd.data)) {
+		pos = cursor - uint64(len(rd.data))
+	}
+
+	for ; pos < cursor; pos += 8 {
+		word := atomic.LoadUint64(ringWord(rd.data, pos))
+		if uint32(word) == uint32(ringTag(pos)) && pos+8+(word>>32) <= cursor {
+			break
+		}
+	}
+
+	rd.pos = min(pos, cursor)
+}
+
+// Return the tag of the entry at `pos`, never zero so that empty rings have
+// no entries.
+func ringTag(pos uint64) uint64 {
+	return uint64(uint32(pos/8) + 1)
+}
+
+// Return the word at `pos` of ring data `data`.
+func ringWord(data []byte, pos uint64) *uint64 {
+	return (*uint64)(unsafe.Pointer(&data[pos%uint64(len(data))]))
+}
+
+// Copy `b` to `pos` of ring data `data`, wrapping around its end.
+func ringCopy(data []byte, pos uint64, b []byte) {
+	n := copy(data[pos%uint64(len(data)):], b)
+	copy(data, b[n:])
+}
+
+// Append the `size` bytes at `pos` of ring data `data` to `b`, wrapping
+// around its end.
+func ringRead(data []byte, pos uint64, b []byte, size uint64) []byte {
+	start := pos % uint64(len(data))
+	end := min(start+size, uint64(len(data)))
+	b = append(b, data[start:end]...)
+	return append(b, data[:size-(end-start)]...)
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package debug
+
+import "os"
+
+// Memory maps are not supported.
+func mapRing(f *os.File, size int, writable bool) ([]byte, error) {
+	return nil, ErrRingUnsupported
+}
+
+// Memory maps are not supported.
+func unmapRing(mem []byte) error {
+	return nil
+}
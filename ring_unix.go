@@ -0,0 +1,22 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package debug
+
+import (
+	"os"
+	"syscall"
+)
+
+// Map `size` bytes of `f` in memory, shared with other processes.
+func mapRing(f *os.File, size int, writable bool) ([]byte, error) {
+	prot := syscall.PROT_READ
+	if writable {
+		prot |= syscall.PROT_WRITE
+	}
+	return syscall.Mmap(int(f.Fd()), 0, size, prot, syscall.MAP_SHARED)
+}
+
+// Unmap memory mapped by mapRing.
+func unmapRing(mem []byte) error {
+	return syscall.Munmap(mem)
+}
@@ -0,0 +1,155 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package debug
+
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+import "sync"
+import "testing"
+import "time"
+
+func TestRing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ring")
+
+	s, err := NewRingSink(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rd, err := OpenRing(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+
+	if _, err := rd.Read(); err != io.EOF {
+		t.Fatalf("expected io.EOF on an empty ring, got %v", err)
+	}
+
+	s.Write(&Record{Time: time.Now(), Name: "db", Level: LevelWarn, Message: "hello", Fields: []KV{{"ms", 12}}})
+
+	r, err := rd.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Name != "db" || r.Level != LevelWarn || r.Message != "hello" || len(r.Fields) != 1 {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	if _, err := rd.Read(); err != io.EOF {
+		t.Fatalf("expected io.EOF once read, got %v", err)
+	}
+}
+
+func TestRingTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ring")
+
+	s, err := NewRingSink(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	if err := os.Truncate(path, ringHeader+8); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenRing(path); err != ErrRing {
+		t.Fatalf("expected ErrRing, got %v", err)
+	}
+}
+
+func TestRingOverrun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ring")
+
+	s, err := NewRingSink(path, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	rd, err := OpenRing(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+
+	for i := 0; i < 1000; i++ {
+		s.Write(&Record{Name: "x", Message: fmt.Sprint(i)})
+	}
+
+	s.Write(&Record{Name: "x", Message: string(make([]byte, 5000))})
+	if s.Dropped() != 1 {
+		t.Fatalf("expected a record larger than the ring to be dropped")
+	}
+
+	var got []string
+	for {
+		r, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.Message)
+	}
+
+	if rd.Overruns() != 1 || len(got) < 10 || got[len(got)-1] != "999" {
+		t.Fatalf("expected the latest records after an overrun, got %d overruns and %v", rd.Overruns(), got)
+	}
+
+	for i := 1; i < len(got); i++ {
+		if got[i] != fmt.Sprint(1000-len(got)+i) {
+			t.Fatalf("expected consecutive records, got %v", got)
+		}
+	}
+}
+
+func TestRingConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ring")
+
+	s, err := NewRingSink(path, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				s.Write(&Record{Name: "x", Message: fmt.Sprint(i)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	rd, err := OpenRing(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+
+	n := 0
+	for {
+		_, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+
+	if n != 2000 {
+		t.Fatalf("expected 2000 records, got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package debug
+
+import "io"
+
+// SetRoute replaces the writer with one writing the namespaces matching
+// `pattern` to `matched` and the others to `rest`, for platforms treating
+// streams differently, such as keeping stdout longer:
+//
+//	debug.SetRoute("audit:*", os.Stdout, os.Stderr)
+//
+// Both writers are flushed along with the writer, see SetSync.
+func SetRoute(pattern string, matched, rest io.Writer) {
+	m.Lock()
+	p := compile(pattern, false)
+	m.Unlock()
+
+	SetWriter(&routeWriter{pat: p, matched: matched, rest: rest})
+}
+
+// A writer of output to one of two writers by namespace, which is muted,
+// counted and drawn above the status line like the output of any writer.
+// It is only used with the package lock held.
+type routeWriter struct {
+	pat     *pattern
+	matched io.Writer
+	rest    io.Writer
+}
+
+// Write implements io.Writer, writing output not belonging to a namespace
+// to the writer of the other namespaces.
+func (w *routeWriter) Write(b []byte) (int, error) {
+	return w.rest.Write(b)
+}
+
+// Flush flushes both writers.
+func (w *routeWriter) Flush() error {
+	flush(w.matched)
+	flush(w.rest)
+	return nil
+}
+
+// Sync flushes both writers and commits them to stable storage.
+func (w *routeWriter) Sync() error {
+	syncWriter(w.matched)
+	syncWriter(w.rest)
+	return nil
+}
+
+// Return the writer of namespace `name`.
+func (w *routeWriter) route(name string) io.Writer {
+	if w.pat.match(name) {
+		return w.matched
+	}
+	return w.rest
+}
@@ -0,0 +1,41 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestSetRoute(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	SetRoute("audit:*", stdout, stderr)
+
+	Debug("audit:login")("tobi logged in")
+	Debug("audit")("audited")
+	Debug("db")("connected")
+
+	assertContains(t, stdout.String(), "tobi logged in")
+	assertContains(t, stdout.String(), "audited")
+	assertNotContains(t, stdout.String(), "connected")
+	assertContains(t, stderr.String(), "connected")
+	assertNotContains(t, stderr.String(), "audit")
+}
+
+func TestSetRouteMuteAndStats(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	SetRoute("routed:*", stdout, stderr)
+
+	unmute := MuteWith(MuteDrop)
+	Debug("routed:muted")("dropped")
+	unmute()
+
+	Debug("routed:counted")("counted")
+
+	assertNotContains(t, stdout.String(), "dropped")
+	assertContains(t, stdout.String(), "counted")
+
+	if st := stat("routed:counted"); st.Bytes == 0 {
+		t.Fatalf("expected routed bytes to be counted, got %+v", st)
+	}
+}
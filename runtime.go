@@ -0,0 +1,71 @@
+package debug
+
+import (
+	"runtime"
+	"time"
+)
+
+// RuntimeStats starts emitting runtime telemetry every `interval` through
+// the built-in namespaces "runtime:gc" (collections, last pause, heap
+// usage) and "runtime:sched" (goroutines, GOMAXPROCS, cgo calls). Nothing
+// is sampled while both namespaces are disabled. Call the returned
+// function to stop; it returns once no more output will be written.
+func RuntimeStats(interval time.Duration) (stop func()) {
+	gc := Named("runtime:gc")
+	sched := Named("runtime:sched")
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer close(exited)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if gc.Enabled() {
+					gc.emit(LevelDebug, "stats", gcFields())
+				}
+				if sched.Enabled() {
+					sched.emit(LevelDebug, "stats", schedFields())
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// Return garbage collector fields.
+func gcFields() []KV {
+	var s runtime.MemStats
+	runtime.ReadMemStats(&s)
+
+	var pause time.Duration
+	if s.NumGC > 0 {
+		pause = time.Duration(s.PauseNs[(s.NumGC+255)%256])
+	}
+
+	return []KV{
+		{"num_gc", s.NumGC},
+		{"last_pause", pause},
+		{"pause_total", time.Duration(s.PauseTotalNs)},
+		{"heap_alloc", s.HeapAlloc},
+		{"heap_sys", s.HeapSys},
+		{"heap_objects", s.HeapObjects},
+	}
+}
+
+// Return scheduler fields.
+func schedFields() []KV {
+	return []KV{
+		{"goroutines", runtime.NumGoroutine()},
+		{"gomaxprocs", runtime.GOMAXPROCS(0)},
+		{"cgo_calls", runtime.NumCgoCall()},
+	}
+}
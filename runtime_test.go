@@ -0,0 +1,23 @@
+package debug
+
+import "bytes"
+import "testing"
+import "time"
+
+func TestRuntimeStats(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("runtime:sched")
+
+	stop := RuntimeStats(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	str := buf.String()
+
+	assertContains(t, str, "runtime:sched")
+	assertContains(t, str, "goroutines=")
+	assertNotContains(t, str, "runtime:gc")
+}
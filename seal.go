@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+)
+
+// SealConn wraps `conn` so that data written to it is encrypted and
+// authenticated with AES-GCM under `key`, of 16, 24 or 32 bytes, and data
+// read from it is decrypted. Each Write is sent as one frame: a 4-byte
+// big-endian length followed by a random nonce and the ciphertext.
+//
+// NetSink uses it when DialOptions.Key is set, and the debugd server when
+// its Key is, so both ends must share the key. Unlike TLS it needs no
+// certificates, but neither does it protect against replayed frames.
+func SealConn(conn net.Conn, key []byte) (net.Conn, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealedConn{Conn: conn, aead: aead}, nil
+}
+
+// Connection encrypting frames, see SealConn.
+type sealedConn struct {
+	net.Conn
+	aead cipher.AEAD
+
+	wbuf []byte
+	rbuf []byte
+	rest []byte
+}
+
+// Write implements io.Writer.
+func (c *sealedConn) Write(p []byte) (int, error) {
+	size := c.aead.NonceSize()
+	b := append(c.wbuf[:0], 0, 0, 0, 0)
+	b = append(b, make([]byte, size)...)
+
+	nonce := b[4 : 4+size]
+	if _, err := rand.Read(nonce); err != nil {
+		return 0, err
+	}
+
+	b = c.aead.Seal(b, nonce, p, nil)
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	c.wbuf = b
+
+	if _, err := c.Conn.Write(b); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Read implements io.Reader.
+func (c *sealedConn) Read(p []byte) (int, error) {
+	for len(c.rest) == 0 {
+		if err := c.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, c.rest)
+	c.rest = c.rest[n:]
+	return n, nil
+}
+
+// Read and decrypt the next frame into rest.
+func (c *sealedConn) readFrame() error {
+	var head [4]byte
+	if _, err := io.ReadFull(c.Conn, head[:]); err != nil {
+		return err
+	}
+
+	n := binary.BigEndian.Uint32(head[:])
+	size := c.aead.NonceSize()
+	if n < uint32(size+c.aead.Overhead()) || n > maxFrame {
+		return errors.New("debug: invalid sealed frame")
+	}
+
+	if cap(c.rbuf) < int(n) {
+		c.rbuf = make([]byte, n)
+	}
+	b := c.rbuf[:n]
+	if _, err := io.ReadFull(c.Conn, b); err != nil {
+		return err
+	}
+
+	plain, err := c.aead.Open(b[size:size], b[:size], b[size:], nil)
+	if err != nil {
+		return errors.New("debug: sealed frame failed authentication")
+	}
+
+	c.rest = plain
+	return nil
+}
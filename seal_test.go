@@ -0,0 +1,71 @@
+package debug
+
+import "bytes"
+import "io"
+import "net"
+import "testing"
+
+func TestSealConn(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 16)
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	w, err := SealConn(a, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := SealConn(b, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		w.Write([]byte("hello "))
+		w.Write([]byte("world\n"))
+	}()
+
+	got := make([]byte, 12)
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "hello world\n" {
+		t.Fatalf("unexpected %q", got)
+	}
+}
+
+func TestSealConnCiphertext(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	w, _ := SealConn(a, bytes.Repeat([]byte{3}, 16))
+	go w.Write([]byte("secret"))
+
+	raw := make([]byte, 4+12+6+16)
+	if _, err := io.ReadFull(b, raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(raw, []byte("secret")) {
+		t.Fatal("expected the data to be encrypted")
+	}
+
+	r, _ := SealConn(b, bytes.Repeat([]byte{4}, 16))
+	go w.Write([]byte("secret"))
+	if _, err := r.Read(make([]byte, 6)); err == nil {
+		t.Fatal("expected an authentication error with the wrong key")
+	}
+}
+
+func TestSealConnKey(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	if _, err := SealConn(a, []byte("short")); err == nil {
+		t.Fatal("expected an error for an invalid key size")
+	}
+}
@@ -0,0 +1,39 @@
+package debug
+
+// Sink receives every record written by an enabled namespace, in addition
+// to the writer. Write is called with the package lock held, so it should
+// not block for long, and must not change the configuration. It may get
+// namespaces with Named and the functions built on it, such as V, but the
+// records it logs itself, like those logged by writers and formatters, are
+// dropped rather than deadlocking. Records are reused, see Record.
+type Sink interface {
+	Write(r *Record) error
+}
+
+// AddSink adds `s` to the outputs. Call the returned function to remove
+// it again.
+func AddSink(s Sink) (remove func()) {
+	m.Lock()
+	defer m.Unlock()
+
+	e := &sinkEntry{s}
+	sinks = append(sinks, e)
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, v := range sinks {
+			if v == e {
+				sinks = append(sinks[:i:i], sinks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// A sinkEntry gives each added sink an identity, so that removing it works
+// for sinks which are not comparable.
+type sinkEntry struct {
+	Sink
+}
@@ -0,0 +1,135 @@
+package debug
+
+import "bytes"
+import "context"
+import "encoding/json"
+import "testing"
+import "time"
+
+type recordSink struct {
+	records []*Record
+}
+
+func (s *recordSink) Write(r *Record) error {
+	s.records = append(s.records, r.Clone())
+	return nil
+}
+
+func TestAddSink(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("sink")
+
+	s := &recordSink{}
+	remove := AddSink(s)
+
+	Debug("sink")("one")
+	Debug("other")("ignored")
+	remove()
+	Debug("sink")("two")
+
+	if len(s.records) != 1 || s.records[0].Message != "one" {
+		t.Fatalf("unexpected records %v", s.records)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+	SetFormat(JSONFormat)
+	defer SetFormat(TextFormat)
+
+	Enable("parse")
+	n := Named("parse")
+	n.emit(LevelDebug, "hello", []KV{{"b", "x"}, {"a", 1}})
+
+	r, err := ParseJSON(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Name != "parse" || r.Message != "hello" || r.Time.IsZero() {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	if len(r.Fields) != 2 || r.Fields[0].Key != "b" || r.Fields[1].Value.(json.Number) != "1" {
+		t.Fatalf("unexpected fields %v", r.Fields)
+	}
+}
+
+// A sink which logs every record it receives.
+type loggingSink struct {
+	n *Namespace
+}
+
+func (s loggingSink) Write(r *Record) error {
+	s.n.Printf("saw %s", r.Message)
+	s.n.PrintfContext(context.Background(), "saw %s", r.Message)
+	return nil
+}
+
+func TestReentrantSink(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "reentrant")()
+
+	n := Named("reentrant")
+	defer AddSink(loggingSink{n})()
+
+	done := make(chan struct{})
+	go func() {
+		n.Printf("hello")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deadlocked")
+	}
+
+	assertContains(t, buf.String(), "hello")
+	assertNotContains(t, buf.String(), "saw")
+}
+
+// A sink which creates namespaces and logs to them.
+type namingSink struct{}
+
+func (namingSink) Write(r *Record) error {
+	Named("reentrant:named").Printf("saw %s", r.Message)
+	V("reentrant:verbose", 0)("saw %s", r.Message)
+	Tagged("reentrant:tagged").Printf("saw %s", r.Message)
+	return nil
+}
+
+func TestReentrantNamed(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "reentrant*")()
+	defer AddSink(namingSink{})()
+
+	done := make(chan struct{})
+	go func() {
+		Named("reentrant").Printf("hello")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deadlocked")
+	}
+
+	assertContains(t, buf.String(), "hello")
+	assertNotContains(t, buf.String(), "saw")
+
+	found := false
+	for _, name := range Namespaces() {
+		found = found || name == "reentrant:named"
+	}
+	if !found {
+		t.Fatalf("expected the namespace to be registered")
+	}
+}
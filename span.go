@@ -0,0 +1,81 @@
+package debug
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeline accumulates events for an operation which may span several
+// goroutines, and prints them as one consolidated block on End so that
+// output from concurrent pipelines stays readable. It is safe to share
+// a Timeline between goroutines.
+type Timeline struct {
+	n      *Namespace
+	start  time.Time
+	m      sync.Mutex
+	events []spanEvent
+	ended  bool
+}
+
+// A single event of a timeline.
+type spanEvent struct {
+	offset time.Duration
+	msg    string
+}
+
+// Span creates a Timeline for namespace `name`, for example "job:123".
+// Span namespaces are not registered, see Namespaces, so that names such
+// as job IDs do not accumulate.
+func Span(name string) *Timeline {
+	return &Timeline{
+		n:     newNamespace(name),
+		start: time.Now(),
+	}
+}
+
+// Event records a printf-style event. Events are only collected while the
+// namespace is enabled, and are ignored once the timeline has ended.
+func (t *Timeline) Event(format string, args ...interface{}) {
+	if !t.n.Enabled() {
+		return
+	}
+
+	e := spanEvent{
+		offset: time.Since(t.start),
+		msg:    fmt.Sprintf(format, args...),
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if !t.ended {
+		t.events = append(t.events, e)
+	}
+}
+
+// End prints the timeline, one indented line per event with its offset
+// from the start of the span. Calling End more than once has no effect.
+func (t *Timeline) End() {
+	t.m.Lock()
+	if t.ended {
+		t.m.Unlock()
+		return
+	}
+	t.ended = true
+	events := t.events
+	t.m.Unlock()
+
+	if !t.n.Enabled() {
+		return
+	}
+
+	elapsed := humanizeNano(time.Since(t.start).Nanoseconds())
+	lines := []string{fmt.Sprintf("%d events over %s", len(events), elapsed)}
+	for _, e := range events {
+		lines = append(lines, fmt.Sprintf("    +%-6s %s", humanizeNano(e.offset.Nanoseconds()), e.msg))
+	}
+
+	t.n.emit(LevelDebug, strings.Join(lines, "\n"), nil)
+}
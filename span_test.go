@@ -0,0 +1,50 @@
+package debug
+
+import "bytes"
+import "strings"
+import "sync"
+import "testing"
+
+func TestSpan(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("job:*")
+
+	sp := Span("job:123")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sp.Event("worker %d done", i)
+		}(i)
+	}
+	wg.Wait()
+
+	if buf.Len() != 0 {
+		t.Fatalf("events should not be printed before End")
+	}
+
+	sp.End()
+	sp.End()
+	sp.Event("late")
+
+	str := buf.String()
+	assertContains(t, str, "3 events over")
+	assertContains(t, str, "worker 0 done")
+	assertContains(t, str, "worker 2 done")
+	assertNotContains(t, str, "late")
+
+	for _, name := range Namespaces() {
+		if name == "job:123" {
+			t.Fatal("expected span namespaces not to be registered")
+		}
+	}
+
+	if c := strings.Count(str, "job:123"); c != 1 {
+		t.Fatalf("expected a single timeline, got %d", c)
+	}
+}
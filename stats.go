@@ -0,0 +1,73 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// Counters of a namespace. Only the suppressed calls are counted outside
+// of the lock, by any number of goroutines at once, and are sharded.
+type counters struct {
+	emitted    atomic.Uint64
+	suppressed shardedCounter
+	dropped    atomic.Uint64
+	bytes      atomic.Uint64
+}
+
+// Stat holds the usage counters of a namespace. Suppressed counts the
+// calls made while the namespace was disabled, Dropped the records
+// discarded by the policy of an AsyncSink or by full subscriptions, and
+// Bytes the output written for it.
+type Stat struct {
+	Name       string
+	Emitted    uint64
+	Suppressed uint64
+	Dropped    uint64
+	Bytes      uint64
+}
+
+// Stats returns the counters of all registered namespaces, sorted by name.
+func Stats() []Stat {
+	m.Lock()
+	defer m.Unlock()
+
+	stats := make([]Stat, 0, len(names))
+	for _, n := range names {
+		stats = append(stats, Stat{
+			Name:       n.name,
+			Emitted:    n.stats.emitted.Load(),
+			Suppressed: n.stats.suppressed.Load(),
+			Dropped:    n.stats.dropped.Load(),
+			Bytes:      n.stats.bytes.Load(),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats
+}
+
+// WriteReport writes a table of the namespaces which were used, with how
+// many messages were emitted, suppressed and dropped, and the bytes
+// written. Go has no exit hooks, so to get a summary on exit defer it in
+// main:
+//
+//	defer debug.WriteReport(os.Stderr)
+func WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "namespace\temitted\tsuppressed\tdropped\tbytes\t\n")
+
+	for _, s := range Stats() {
+		if s.Emitted == 0 && s.Suppressed == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t\n", s.Name, s.Emitted, s.Suppressed, s.Dropped, s.Bytes)
+	}
+
+	return tw.Flush()
+}
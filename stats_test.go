@@ -0,0 +1,41 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func stat(name string) Stat {
+	for _, s := range Stats() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return Stat{}
+}
+
+func TestStats(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("stats:on")
+
+	on := Named("stats:on")
+	off := Named("stats:off")
+	on.Printf("one")
+	on.Printf("two")
+	off.Printf("hidden")
+
+	s := stat("stats:on")
+	if s.Emitted != 2 || s.Suppressed != 0 || s.Bytes != uint64(buf.Len()) {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	if s := stat("stats:off"); s.Suppressed != 1 || s.Emitted != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	var report bytes.Buffer
+	WriteReport(&report)
+	assertContains(t, report.String(), "stats:off")
+	assertNotContains(t, report.String(), "stats:unused")
+}
@@ -0,0 +1,66 @@
+package debug
+
+import (
+	"io"
+	"strings"
+)
+
+// Escape sequence returning to the start of the line and clearing it.
+const clearLine = "\r\033[K"
+
+var (
+	status    string
+	statusOut []byte
+)
+
+// SetStatus shows `line` as a status line at the bottom of the writer,
+// such as the progress of a CLI, and keeps it there: each record clears
+// it, is written in its place, and the status line is drawn again below.
+// Call it again to update the line, and with an empty line to clear it
+// before exiting or writing to the terminal otherwise:
+//
+//	for i, f := range files {
+//		debug.SetStatus(fmt.Sprintf("uploading %d/%d", i+1, len(files)))
+//		upload(f)
+//	}
+//	debug.SetStatus("")
+//
+// The writer should be a terminal, and `line` should be narrower than it,
+// since a wrapped line cannot be cleared. Newlines are replaced by spaces.
+//
+// This function is thread-safe.
+func SetStatus(line string) {
+	line = strings.ReplaceAll(line, "\n", " ")
+
+	m.Lock()
+	defer m.Unlock()
+
+	if line == status {
+		return
+	}
+
+	prev := status
+	status = line
+	if muteBuffers+muteDrops > 0 {
+		return
+	}
+
+	if prev != "" || line != "" {
+		statusOut = append(append(statusOut[:0], clearLine...), line...)
+		writer.Write(statusOut)
+	}
+}
+
+// Write `b` to `w`, the writer or the one it routes to, above the status
+// line if any. The lock must be held.
+func writeOutput(w io.Writer, b []byte) {
+	if status == "" {
+		w.Write(b)
+		return
+	}
+
+	statusOut = append(statusOut[:0], clearLine...)
+	statusOut = append(statusOut, b...)
+	statusOut = append(statusOut, status...)
+	w.Write(statusOut)
+}
@@ -0,0 +1,44 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestSetStatus(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "upload")()
+	defer SetStatus("")
+
+	log := Named("upload")
+	SetStatus("uploading 1/2")
+	SetStatus("uploading 1/2")
+	log.Printf("sent a.txt")
+	SetStatus("uploading\n2/2")
+	SetStatus("")
+
+	str := buf.String()
+	assertContains(t, str, "\r\033[Kuploading 1/2\r\033[K")
+	assertContains(t, str, " - sent a.txt\nuploading 1/2\r\033[Kuploading 2/2\r\033[K")
+	if bytes.Count(buf.Bytes(), []byte("uploading 1/2")) != 2 {
+		t.Fatalf("expected the status to be drawn twice, got %q", str)
+	}
+
+	buf.Reset()
+	log.Printf("done")
+	assertNotContains(t, buf.String(), "\r")
+}
+
+func TestSetStatusMute(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "upload")()
+	defer SetStatus("")
+
+	SetStatus("working")
+	unmute := Mute()
+	SetStatus("still working")
+	buf.Reset()
+	unmute()
+
+	if str := buf.String(); str != "\r\033[Kstill working" {
+		t.Fatalf("expected the status to be redrawn, got %q", str)
+	}
+}
@@ -0,0 +1,8 @@
+package debug
+
+import "syscall"
+
+// Duplicate `oldfd` onto `newfd`. Dup2 is missing on some architectures.
+func dup2(oldfd, newfd int) error {
+	return syscall.Dup3(oldfd, newfd, 0)
+}
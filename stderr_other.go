@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package debug
+
+import "os"
+
+// Replace os.Stderr with `w`, returning the original and a function
+// undoing the replacement. Writes made to the file descriptor directly
+// are not captured on this platform.
+func redirectStderr(w *os.File) (*os.File, func(), error) {
+	orig := os.Stderr
+	os.Stderr = w
+	return orig, func() {
+		os.Stderr = orig
+	}, nil
+}
@@ -0,0 +1,28 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package debug
+
+import (
+	"os"
+	"syscall"
+)
+
+// Point file descriptor 2 at `w`, returning a file for the original
+// standard error and a function undoing the redirection. The file stays
+// open until the caller closes it.
+func redirectStderr(w *os.File) (*os.File, func(), error) {
+	fd, err := syscall.Dup(2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := dup2(int(w.Fd()), 2); err != nil {
+		syscall.Close(fd)
+		return nil, nil, err
+	}
+
+	orig := os.NewFile(uintptr(fd), "/dev/stderr")
+	return orig, func() {
+		dup2(fd, 2)
+	}, nil
+}
@@ -0,0 +1,81 @@
+package debug
+
+// Size of the buffer of subscription channels.
+const subscriptionSize = 256
+
+// A subscription, see Subscribe.
+type subscription struct {
+	pat    *pattern
+	ch     chan *Record
+	remove func()
+}
+
+// Subscriptions by channel, the lock must be held.
+var subscriptions = map[<-chan *Record]*subscription{}
+
+// Subscribe returns a channel receiving a copy of the records of the
+// namespaces matching `pattern`, so that application code and embedded
+// dashboards can consume debug output in-process:
+//
+//	ch := debug.Subscribe("jobs:*")
+//	defer debug.Unsubscribe(ch)
+//	for r := range ch {
+//		dashboard.Add(r.Name, r.Message)
+//	}
+//
+// Like sinks, subscriptions only receive the records of enabled
+// namespaces. Records are dropped rather than blocking the program when
+// the buffer of the channel is full, and counted in the Dropped field of
+// the Stats of their namespace.
+//
+// This function is thread-safe.
+func Subscribe(pattern string) <-chan *Record {
+	m.Lock()
+	s := &subscription{
+		pat: compile(pattern, false),
+		ch:  make(chan *Record, subscriptionSize),
+	}
+	m.Unlock()
+
+	remove := AddSink(s)
+
+	m.Lock()
+	s.remove = remove
+	subscriptions[s.ch] = s
+	m.Unlock()
+
+	return s.ch
+}
+
+// Unsubscribe stops the subscription of `ch` returned by Subscribe, and
+// closes it once the records already sent are received.
+//
+// This function is thread-safe.
+func Unsubscribe(ch <-chan *Record) {
+	m.Lock()
+	s, ok := subscriptions[ch]
+	delete(subscriptions, ch)
+	m.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// Sinks are written under the lock, so none is sending anymore.
+	s.remove()
+	close(s.ch)
+}
+
+// Write implements Sink.
+func (s *subscription) Write(r *Record) error {
+	if !s.pat.match(r.Name) {
+		return nil
+	}
+
+	select {
+	case s.ch <- r.Clone():
+	default:
+		countDropped(r)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestSubscribe(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "*")()
+
+	ch := Subscribe("jobs:*")
+	Named("jobs:mail").Printf("sent %d", 3)
+	Named("http").Printf("ignored")
+
+	r := <-ch
+	if r.Name != "jobs:mail" || r.Message != "sent 3" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	for i := 0; i < subscriptionSize+10; i++ {
+		Named("jobs:overflow").Printf("line")
+	}
+
+	if dropped := Named("jobs:overflow").stats.dropped.Load(); dropped != 10 {
+		t.Fatalf("expected 10 dropped records, got %d", dropped)
+	}
+
+	Unsubscribe(ch)
+	Named("jobs:mail").Printf("after")
+
+	n := 0
+	for range ch {
+		n++
+	}
+
+	if n != subscriptionSize {
+		t.Fatalf("expected the buffered records before the channel is closed, got %d", n)
+	}
+
+	Unsubscribe(ch)
+}
@@ -0,0 +1,48 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package debug
+
+import "log/syslog"
+
+// SyslogSink is a Sink writing records to the system logger, with levels
+// mapped to syslog priorities.
+type SyslogSink struct {
+	w   *syslog.Writer
+	buf []byte
+}
+
+// NewSyslogSink connects to the local syslog daemon, logging as `tag`.
+// Add it with AddSink.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	w, err := syslog.New(syslog.LOG_USER|syslog.LOG_DEBUG, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyslogSink{w: w}, nil
+}
+
+// Write implements Sink.
+func (s *SyslogSink) Write(r *Record) error {
+	s.buf = append(s.buf[:0], r.Name...)
+	s.buf = append(s.buf, " - "...)
+	s.buf = append(s.buf, r.Message...)
+	s.buf = appendFields(s.buf, r.Fields)
+	msg := string(s.buf)
+
+	switch r.Level {
+	case LevelInfo:
+		return s.w.Info(msg)
+	case LevelWarn:
+		return s.w.Warning(msg)
+	case LevelError:
+		return s.w.Err(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
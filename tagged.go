@@ -0,0 +1,32 @@
+package debug
+
+import "strconv"
+
+// Last tag handed out for each name, see Tagged.
+var tags = map[string]int{}
+
+// Tagged returns a new namespace for `name` with a short numbered tag, such
+// as "worker:pool#7", so that pools of identical workers produce
+// distinguishable output. Call it once per goroutine or worker:
+//
+//	for i := 0; i < 8; i++ {
+//		go func() {
+//			log := debug.Tagged("worker:pool")
+//			...
+//		}()
+//	}
+//
+// Patterns matching the name also match its tagged namespaces, so that
+// "worker:pool" enables every worker, and "worker:pool#7" a single one.
+// Tagged namespaces are not registered, see Namespaces, so that short-lived
+// workers do not accumulate.
+func Tagged(name string) *Namespace {
+	locked := lockUnlessWriting()
+	tags[name]++
+	tag := tags[name]
+	if locked {
+		m.Unlock()
+	}
+
+	return newNamespace(name + "#" + strconv.Itoa(tag))
+}
@@ -0,0 +1,36 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestTagged(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("tagged:pool")
+	defer Disable()
+
+	a, c := Tagged("tagged:pool"), Tagged("tagged:pool")
+	if a.Name() != "tagged:pool#1" || c.Name() != "tagged:pool#2" {
+		t.Fatalf("unexpected names %q and %q", a.Name(), c.Name())
+	}
+
+	a.Printf("first")
+	c.Printf("second")
+	assertContains(t, buf.String(), "tagged:pool#1\033[0m - first")
+	assertContains(t, buf.String(), "tagged:pool#2\033[0m - second")
+
+	Enable("tagged:pool#2")
+	buf.Reset()
+	a.Printf("first")
+	c.Printf("second")
+	assertNotContains(t, buf.String(), "first")
+	assertContains(t, buf.String(), "second")
+
+	for _, name := range Namespaces() {
+		if name == "tagged:pool#1" {
+			t.Fatalf("expected tagged namespaces not to be registered")
+		}
+	}
+}
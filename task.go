@@ -0,0 +1,102 @@
+package debug
+
+import (
+	"fmt"
+	rdebug "runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Runner runs functions in goroutines, like errgroup.Group.
+type Runner interface {
+	Go(f func() error)
+}
+
+// TaskGroup instruments the tasks of a group of goroutines, such as an
+// errgroup.Group or a worker pool, giving each one a namespace of its own
+// and writing its start, and its end with its duration and error, so that
+// concurrency bugs can be followed without boilerplate, see Tasks.
+type TaskGroup struct {
+	name    string
+	runner  Runner
+	seq     atomic.Uint64
+	running atomic.Int64
+}
+
+// Tasks returns a TaskGroup running tasks with `runner`, which may be nil
+// for tasks only run through Wrap. Each task has a namespace derived from
+// `name`, such as "crawl:task:3" for "crawl:task", enabled by patterns such
+// as "crawl:task:*" as usual:
+//
+//	var g errgroup.Group
+//	tasks := debug.Tasks("crawl:task", &g)
+//	for _, url := range urls {
+//		tasks.Go(func(log *debug.Namespace) error {
+//			log.Printf("fetching %s", url)
+//			return fetch(url)
+//		})
+//	}
+//	err := g.Wait()
+//
+// Task namespaces are not registered, see Namespaces.
+func Tasks(name string, runner Runner) *TaskGroup {
+	return &TaskGroup{name: name, runner: runner}
+}
+
+// Go runs `fn` as a task with the runner of the group.
+func (g *TaskGroup) Go(fn func(n *Namespace) error) {
+	g.runner.Go(g.Wrap(fn))
+}
+
+// Wrap returns `fn` as a task, for worker pools taking functions rather
+// than a Runner:
+//
+//	jobs <- tasks.Wrap(func(log *debug.Namespace) error { ... })
+//
+// The task is numbered when it starts. Panics are written as errors with
+// their stack and propagated, and tasks ending with runtime.Goexit are
+// written as exited.
+func (g *TaskGroup) Wrap(fn func(n *Namespace) error) func() error {
+	return func() (err error) {
+		id := strconv.FormatUint(g.seq.Add(1), 10)
+		n := newNamespace(g.name + Separator() + id)
+
+		running := g.running.Add(1)
+		defer g.running.Add(-1)
+
+		if !n.Enabled() {
+			return fn(n)
+		}
+
+		n.emit(LevelDebug, "start", []KV{{"running", running}})
+		start := time.Now()
+
+		panicked := true
+		defer func() {
+			elapsed := time.Since(start)
+			switch {
+			case panicked:
+				// Re-panicking starts a new trace, so the stack of the
+				// panic is kept in the record. A nil value is a
+				// runtime.Goexit, such as t.FailNow, left to unwind.
+				v := recover()
+				if v == nil {
+					n.emit(LevelWarn, "exited", withDuration(nil, elapsed))
+					return
+				}
+
+				n.emit(LevelError, fmt.Sprintf("panic: %v", v), withDuration([]KV{{"stack", string(rdebug.Stack())}}, elapsed))
+				panic(v)
+			case err != nil:
+				n.emit(LevelWarn, "failed: "+err.Error(), withDuration([]KV{{"error", err.Error()}}, elapsed))
+			default:
+				n.emit(LevelDebug, "done", withDuration(nil, elapsed))
+			}
+		}()
+
+		err = fn(n)
+		panicked = false
+		return err
+	}
+}
@@ -0,0 +1,124 @@
+package debug
+
+import "bytes"
+import "errors"
+import "runtime"
+import "sync"
+import "testing"
+
+// A Runner like errgroup.Group.
+type waitGroup struct {
+	wg  sync.WaitGroup
+	m   sync.Mutex
+	err error
+}
+
+func (g *waitGroup) Go(f func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.m.Lock()
+			g.err = err
+			g.m.Unlock()
+		}
+	}()
+}
+
+func (g *waitGroup) Wait() error {
+	g.wg.Wait()
+	return g.err
+}
+
+func TestTasks(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "crawl:*")()
+
+	var g waitGroup
+	tasks := Tasks("crawl", &g)
+
+	tasks.Go(func(log *Namespace) error {
+		log.Printf("fetching")
+		return nil
+	})
+	g.Wait()
+
+	tasks.Go(func(log *Namespace) error {
+		return errors.New("timeout")
+	})
+
+	if err := g.Wait(); err == nil || err.Error() != "timeout" {
+		t.Fatalf("expected the error of the task, got %v", err)
+	}
+
+	out := buf.String()
+	assertContains(t, out, "crawl:1")
+	assertContains(t, out, "- start running=1")
+	assertContains(t, out, "- fetching")
+	assertContains(t, out, "- done duration=")
+	assertContains(t, out, "crawl:2")
+	assertContains(t, out, "WRN")
+	assertContains(t, out, "- failed: timeout error=timeout duration=")
+
+	for _, name := range Namespaces() {
+		if name == "crawl:1" {
+			t.Fatal("expected task namespaces not to be registered")
+		}
+	}
+}
+
+func TestTasksPanic(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "pool:*")()
+
+	task := Tasks("pool", nil).Wrap(func(log *Namespace) error {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Fatalf("expected the panic to propagate, got %v", v)
+			}
+		}()
+		task()
+	}()
+
+	assertContains(t, buf.String(), "ERR")
+	assertContains(t, buf.String(), "- panic: boom stack=goroutine")
+	assertContains(t, buf.String(), "task_test.go")
+}
+
+func TestTasksGoexit(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "exit:*")()
+
+	task := Tasks("exit", nil).Wrap(func(log *Namespace) error {
+		runtime.Goexit()
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		task()
+	}()
+	<-done
+
+	assertContains(t, buf.String(), "- exited duration=")
+	assertNotContains(t, buf.String(), "panic")
+}
+
+func TestTasksDisabled(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "other")()
+
+	err := Tasks("pool", nil).Wrap(func(log *Namespace) error {
+		log.Printf("hidden")
+		return nil
+	})()
+
+	if err != nil || buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,55 @@
+package debug
+
+import "strings"
+
+// TB is the part of testing.TB used by SetTB, so that this package does
+// not depend on the testing package.
+type TB interface {
+	Logf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+// SetTB replaces the writer with `t` until the end of the test, so that
+// output is written with t.Logf: attributed to the test, interleaved with
+// its own logs, and only shown when it fails or with go test -v.
+//
+//	func TestCheckout(t *testing.T) {
+//		debug.SetTB(t)
+//		...
+//	}
+//
+// Colors are not written. The previous writer is restored by t.Cleanup.
+// As the writer is shared, output of parallel tests may be attributed to
+// the wrong one.
+func SetTB(t TB) {
+	prev := SetWriter(&tbWriter{t: t})
+
+	t.Cleanup(func() {
+		m.Lock()
+		defer m.Unlock()
+
+		if w, ok := writer.(*tbWriter); ok && w.t == t {
+			writer = prev
+			colored = useColor(colorMode, prev)
+		}
+	})
+}
+
+// A writer of records to a test log. It is only used with the package
+// lock held.
+type tbWriter struct {
+	t   TB
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (w *tbWriter) Write(b []byte) (int, error) {
+	w.t.Logf("%s", strings.TrimSuffix(string(b), "\n"))
+	return len(b), nil
+}
+
+// Write `r` without colors.
+func (w *tbWriter) writeRecord(r *Record, color string) {
+	w.buf = formatter.Format(w.buf[:0], r, "")
+	w.Write(w.buf)
+}
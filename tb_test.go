@@ -0,0 +1,53 @@
+package debug
+
+import "bytes"
+import "fmt"
+import "testing"
+
+type fakeTB struct {
+	logs     []string
+	cleanups []func()
+}
+
+func (t *fakeTB) Logf(format string, args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTB) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func TestSetTB(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "checkout")()
+
+	tb := &fakeTB{}
+	SetTB(tb)
+
+	Debug("checkout")("charged %d", 42)
+	if len(tb.logs) != 1 {
+		t.Fatalf("expected one log, got %q", tb.logs)
+	}
+
+	assertContains(t, tb.logs[0], "checkout - charged 42")
+	assertNotContains(t, tb.logs[0], "\033[")
+	assertNotContains(t, tb.logs[0], "\n")
+
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+
+	Debug("checkout")("after")
+	if len(tb.logs) != 1 {
+		t.Fatalf("expected the writer to be restored, got %q", tb.logs)
+	}
+	assertContains(t, buf.String(), "after")
+}
+
+func TestSetTBTesting(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "checkout")()
+
+	var _ TB = t
+	SetTB(t)
+	Debug("checkout")("written to the test log")
+}
@@ -0,0 +1,117 @@
+package debug
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields maps field names to values.
+type Fields map[string]interface{}
+
+// TemplateFunction writes a templated line with the given fields.
+type TemplateFunction func(Fields)
+
+// A literal or placeholder of a template.
+type templatePart struct {
+	text        string
+	placeholder bool
+}
+
+// T creates a debug function for `name` writing messages from `template`,
+// in which named placeholders such as "{path}" are replaced by the value
+// of the field of the same name:
+//
+//	handled := debug.T("http", "handled {method} {path} in {dur}")
+//	handled(debug.Fields{"method": r.Method, "path": r.URL.Path, "dur": d})
+//
+// Messages thus keep a consistent, greppable shape. The fields are also
+// attached to the record, in the order of the placeholders followed by
+// the others sorted by name, with the template itself as a "template"
+// field so that sinks can index lines by template. Placeholders without
+// a field are kept as is.
+func T(name, template string) TemplateFunction {
+	n := Named(name)
+	parts := parseTemplate(template)
+
+	return func(fields Fields) {
+		if !n.Enabled() {
+			n.stats.suppressed.Add(1)
+			return
+		}
+
+		var b strings.Builder
+		kvs := make([]KV, 0, len(fields)+1)
+		seen := make(map[string]bool, len(parts))
+
+		for _, p := range parts {
+			if !p.placeholder {
+				b.WriteString(p.text)
+				continue
+			}
+
+			v, ok := fields[p.text]
+			if !ok {
+				b.WriteString("{" + p.text + "}")
+				continue
+			}
+
+			fmt.Fprint(&b, v)
+			if !seen[p.text] {
+				seen[p.text] = true
+				kvs = append(kvs, KV{p.text, v})
+			}
+		}
+
+		rest := make([]string, 0, len(fields)-len(seen))
+		for k := range fields {
+			if !seen[k] {
+				rest = append(rest, k)
+			}
+		}
+		sort.Strings(rest)
+
+		for _, k := range rest {
+			kvs = append(kvs, KV{k, fields[k]})
+		}
+
+		n.emitFormat(LevelDebug, template, b.String(), append(kvs, KV{"template", template}))
+	}
+}
+
+// Split `template` into literals and placeholders. Braces which do not
+// enclose a name are literals.
+func parseTemplate(template string) []templatePart {
+	var parts []templatePart
+
+	for template != "" {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			break
+		}
+
+		j := strings.IndexByte(template[i:], '}')
+		if j < 0 {
+			break
+		}
+
+		name := template[i+1 : i+j]
+		if name == "" || strings.ContainsAny(name, "{ ") {
+			parts = append(parts, templatePart{text: template[:i+1]})
+			template = template[i+1:]
+			continue
+		}
+
+		if i > 0 {
+			parts = append(parts, templatePart{text: template[:i]})
+		}
+		parts = append(parts, templatePart{text: name, placeholder: true})
+		template = template[i+j+1:]
+	}
+
+	if template != "" {
+		parts = append(parts, templatePart{text: template})
+	}
+
+	return parts
+}
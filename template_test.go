@@ -0,0 +1,19 @@
+package debug
+
+import "bytes"
+import "testing"
+import "time"
+
+func TestTemplate(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("tmpl")
+	defer Disable()
+
+	handled := T("tmpl", "handled {method} {path} in {dur} {missing} {} { x}")
+	handled(Fields{"method": "GET", "path": "/", "dur": time.Second, "user": "tj", "agent": "curl"})
+
+	assertContains(t, buf.String(), "- handled GET / in 1s {missing} {} { x} method=GET path=/ dur=1s agent=curl user=tj template=handled {method}")
+}
@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"encoding/hex"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// TraceConn returns `conn` logging its traffic under the namespace
+// "net:<name>": the byte count of every read and write, errors other than
+// io.EOF, and closing. With a verbosity of 1 or more, such as
+// DEBUG=net:*=1, the bytes are also written as a hex dump, which helps
+// debugging protocol implementations.
+func TraceConn(conn net.Conn, name string) net.Conn {
+	return &traceConn{Conn: conn, t: newTracer(name)}
+}
+
+// TraceReader is like TraceConn for reads from `r`.
+func TraceReader(r io.Reader, name string) io.Reader {
+	return &traceReader{r: r, t: newTracer(name)}
+}
+
+// TraceWriter is like TraceConn for writes to `w`.
+func TraceWriter(w io.Writer, name string) io.Writer {
+	return &traceWriter{w: w, t: newTracer(name)}
+}
+
+// Logs the traffic of a traced connection.
+type tracer struct {
+	n *Namespace
+}
+
+// Return the tracer of the namespace "net:<name>".
+func newTracer(name string) tracer {
+	return tracer{Named("net" + Separator() + name)}
+}
+
+// Log an operation `op` transferring `p`, which failed with `err` if set.
+func (t tracer) traffic(op string, p []byte, err error) {
+	if !t.n.Enabled() || (len(p) == 0 && (err == nil || err == io.EOF)) {
+		return
+	}
+
+	msg := op + " " + strconv.Itoa(len(p)) + " bytes"
+	if err != nil && err != io.EOF {
+		msg += ": " + err.Error()
+	}
+
+	if len(p) > 0 && t.n.Verbose(1) {
+		msg += "\n" + strings.TrimSuffix(hex.Dump(p), "\n")
+	}
+
+	t.n.emit(LevelDebug, msg, []KV{{"bytes", len(p)}})
+}
+
+type traceConn struct {
+	net.Conn
+	t tracer
+}
+
+// Read implements net.Conn.
+func (c *traceConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.t.traffic("read", p[:n], err)
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *traceConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.t.traffic("wrote", p[:n], err)
+	return n, err
+}
+
+// Close implements net.Conn.
+func (c *traceConn) Close() error {
+	err := c.Conn.Close()
+	if c.t.n.Enabled() {
+		c.t.n.emit(LevelDebug, "closed", nil)
+	}
+	return err
+}
+
+type traceReader struct {
+	r io.Reader
+	t tracer
+}
+
+// Read implements io.Reader.
+func (r *traceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.t.traffic("read", p[:n], err)
+	return n, err
+}
+
+type traceWriter struct {
+	w io.Writer
+	t tracer
+}
+
+// Write implements io.Writer.
+func (w *traceWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.t.traffic("wrote", p[:n], err)
+	return n, err
+}
@@ -0,0 +1,46 @@
+package debug
+
+import "bytes"
+import "io"
+import "net"
+import "strings"
+import "testing"
+
+func TestTraceConn(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "net:*")()
+
+	client, server := net.Pipe()
+	conn := TraceConn(client, "proto")
+
+	go func() {
+		io.Copy(server, server)
+	}()
+
+	conn.Write([]byte("ping"))
+	reply := make([]byte, 4)
+	io.ReadFull(conn, reply)
+	conn.Close()
+	server.Close()
+
+	str := buf.String()
+	assertContains(t, str, "net:proto\033[0m - wrote 4 bytes bytes=4")
+	assertContains(t, str, "net:proto\033[0m - read 4 bytes bytes=4")
+	assertContains(t, str, "net:proto\033[0m - closed")
+	assertNotContains(t, str, "70 69 6e 67")
+}
+
+func TestTraceReaderDump(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	defer Swap(buf, "net:*=1")()
+
+	io.ReadAll(TraceReader(strings.NewReader("ping"), "file"))
+	io.WriteString(TraceWriter(io.Discard, "sink"), "pong")
+
+	str := buf.String()
+	assertContains(t, str, "read 4 bytes\n00000000  70 69 6e 67")
+	assertContains(t, str, "wrote 4 bytes\n00000000  70 6f 6e 67")
+	assertNotContains(t, str, "read 0 bytes")
+}
@@ -0,0 +1,8 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package debug
+
+import "syscall"
+
+// Request reading terminal attributes.
+const ioctlGetTermios = syscall.TIOCGETA
@@ -0,0 +1,6 @@
+package debug
+
+import "syscall"
+
+// Request reading terminal attributes.
+const ioctlGetTermios = syscall.TCGETS
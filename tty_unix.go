@@ -0,0 +1,26 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package debug
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Check whether `f` is a terminal by reading its terminal attributes, which
+// fails for other character devices such as /dev/null.
+func isatty(f *os.File) bool {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	var errno syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		var t syscall.Termios
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&t)))
+	})
+
+	return err == nil && errno == 0
+}
@@ -0,0 +1,23 @@
+package debug
+
+import (
+	"os"
+	"syscall"
+)
+
+// Check whether `f` is a console. Terminals emulated over pipes, such as
+// mintty, are not detected.
+func isatty(f *os.File) bool {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	var mode uint32
+	var errno error
+	err = rc.Control(func(fd uintptr) {
+		errno = syscall.GetConsoleMode(syscall.Handle(fd), &mode)
+	})
+
+	return err == nil && errno == nil
+}
@@ -0,0 +1,38 @@
+package debug
+
+// Debug function returned by V when disabled.
+func nop(string, ...interface{}) {}
+
+// V returns a debug function for namespace `name` at verbosity `level`,
+// in the style of glog and klog. Verbosity is given in patterns as
+// "name=N", for example with DEBUG=raft=2 the calls V("raft", 1) and
+// V("raft", 2) write while V("raft", 3) does not. A name enabled without a
+// verbosity has verbosity 0.
+//
+//	debug.V("raft", 2)("append entries %d-%d", from, to)
+func V(name string, level int) DebugFunction {
+	return Named(name).V(level)
+}
+
+// V returns a debug function writing to the namespace when it is enabled
+// with a verbosity of at least `level`, see the package function V.
+func (n *Namespace) V(level int) DebugFunction {
+	if !n.Verbose(level) {
+		n.stats.suppressed.Add(1)
+		return nop
+	}
+
+	return n.debugf
+}
+
+// Verbose reports whether the namespace is enabled with a verbosity of at
+// least `level`.
+func (n *Namespace) Verbose(level int) bool {
+	pat := active.Load()
+	if pat == nil {
+		return false
+	}
+
+	l, on := pat.verbosity(n.name)
+	return on && level <= l
+}
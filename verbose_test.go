@@ -0,0 +1,52 @@
+package debug
+
+import "bytes"
+import "testing"
+
+func TestV(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("raft=2,http")
+
+	V("raft", 1)("one")
+	V("raft", 2)("two")
+	V("raft", 3)("three")
+	V("http", 0)("zero")
+	V("http", 1)("http one")
+	Debug("raft")("plain")
+
+	str := buf.String()
+	assertContains(t, str, "one")
+	assertContains(t, str, "two")
+	assertContains(t, str, "zero")
+	assertContains(t, str, "plain")
+	assertNotContains(t, str, "three")
+	assertNotContains(t, str, "http one")
+}
+
+func TestVerbosity(t *testing.T) {
+	cases := []struct {
+		pattern string
+		ordered bool
+		name    string
+		level   int
+		on      bool
+	}{
+		{"raft=2", false, "raft", 2, true},
+		{"raft", false, "raft", 0, true},
+		{"r*=1,raft=3", false, "raft", 3, true},
+		{"raft=3,r*=1", false, "raft", 3, true},
+		{"raft=3,r*=1", true, "raft", 1, true},
+		{"*=4,-raft", false, "raft", 0, false},
+		{"a=b", false, "a=b", 0, true},
+	}
+
+	for _, c := range cases {
+		level, on := compile(c.pattern, c.ordered).verbosity(c.name)
+		if level != c.level || on != c.on {
+			t.Errorf("expected %q verbosity of %q to be %d, %v, got %d, %v", c.pattern, c.name, c.level, c.on, level, on)
+		}
+	}
+}
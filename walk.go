@@ -0,0 +1,66 @@
+package debug
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Walk calls `fn` for every registered namespace in name order, with
+// whether it is enabled and the stack which registered it, as program
+// counters for runtime.CallersFrames, so that large applications can
+// audit where namespaces come from:
+//
+//	debug.Walk(func(ns string, enabled bool, callers []uintptr) {
+//		frame, _ := runtime.CallersFrames(callers).Next()
+//		fmt.Printf("%s %v %s:%d\n", ns, enabled, frame.File, frame.Line)
+//	})
+//
+// The stack starts at the first caller outside of this package, such as
+// the package variable declaring a debugger. Namespaces are registered by
+// their first use, later ones are not recorded.
+func Walk(fn func(ns string, enabled bool, callers []uintptr)) {
+	m.Lock()
+	list := make([]*Namespace, 0, len(names))
+	for _, name := range sortedNames() {
+		list = append(list, names[name])
+	}
+	m.Unlock()
+
+	for _, n := range list {
+		fn(n.name, n.Enabled(), external(n.callers))
+	}
+}
+
+// Return the stack of the caller of Named, including frames of this
+// package, which are only skipped by Walk since symbolizing them would
+// slow down the registration of every namespace.
+func creators() []uintptr {
+	var pcs [32]uintptr
+	return append([]uintptr(nil), pcs[:runtime.Callers(3, pcs[:])]...)
+}
+
+// Return `stack` from its first frame outside of this package.
+func external(stack []uintptr) []uintptr {
+	for len(stack) > 0 && internalFrame(stack[0]) {
+		stack = stack[1:]
+	}
+	return stack
+}
+
+// Check whether the function at `pc`, not counting functions inlined into
+// it, belongs to this package. Frames of its tests do not, so that they
+// see their own call sites.
+func internalFrame(pc uintptr) bool {
+	frames := runtime.CallersFrames([]uintptr{pc})
+
+	var outer runtime.Frame
+	for {
+		frame, more := frames.Next()
+		outer = frame
+		if !more {
+			break
+		}
+	}
+
+	return packagePath(outer.Function) == selfPackage && !strings.HasSuffix(outer.File, "_test.go")
+}
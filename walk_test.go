@@ -0,0 +1,30 @@
+package debug
+
+import "bytes"
+import "runtime"
+import "strings"
+import "testing"
+
+func TestWalk(t *testing.T) {
+	defer Swap(bytes.NewBuffer(nil), "walk:a")()
+
+	Debug("walk:a")
+	Named("walk:b").Extend("c")
+
+	seen := map[string]bool{}
+	Walk(func(ns string, enabled bool, callers []uintptr) {
+		if !strings.HasPrefix(ns, "walk:") {
+			return
+		}
+
+		seen[ns] = enabled
+		frame, _ := runtime.CallersFrames(callers).Next()
+		if !strings.HasSuffix(frame.Function, ".TestWalk") || !strings.HasSuffix(frame.File, "walk_test.go") {
+			t.Errorf("expected %s to be created by TestWalk, got %s at %s:%d", ns, frame.Function, frame.File, frame.Line)
+		}
+	})
+
+	if len(seen) != 3 || !seen["walk:a"] || seen["walk:b"] || seen["walk:b:c"] {
+		t.Fatalf("unexpected namespaces %v", seen)
+	}
+}
@@ -0,0 +1,83 @@
+package debug
+
+import (
+	"context"
+	"time"
+)
+
+// Watch starts a watchdog for an operation under namespace `name`, which
+// warns when the operation is still running after `threshold`, and again
+// with the total time when it completes late. Operations completing in
+// time are silent. The records carry the fields of `ctx`:
+//
+//	defer debug.Watch(ctx, "rpc:call", 2*time.Second)()
+func Watch(ctx context.Context, name string, threshold time.Duration) (done func()) {
+	n := Named(name)
+	if !n.EnabledContext(ctx) {
+		return func() {}
+	}
+
+	start := time.Now()
+	t := time.AfterFunc(threshold, func() {
+		n.emit(LevelWarn, "still running after "+threshold.String(), ContextFields(ctx))
+	})
+
+	return func() {
+		if t.Stop() {
+			return
+		}
+
+		elapsed := time.Since(start)
+		n.emit(LevelWarn, "completed after "+elapsed.String(), withDuration(ContextFields(ctx), elapsed))
+	}
+}
+
+// WatchCtx writes a warning under namespace `name` when `ctx` is done
+// before the returned function is called, with the cause of the
+// cancellation, the time left until the deadline of `ctx` when it had
+// one, negative once passed, and the time since WatchCtx was called as
+// the duration, for debugging timeouts:
+//
+//	ctx, cancel := context.WithTimeoutCause(ctx, time.Second, errSlowBackend)
+//	defer cancel()
+//	defer debug.WatchCtx(ctx, "rpc:call")()
+//
+// The records carry the fields of `ctx`. No goroutine is started.
+func WatchCtx(ctx context.Context, name string) (stop func()) {
+	n := Named(name)
+	if !n.EnabledContext(ctx) {
+		return func() {}
+	}
+
+	start := time.Now()
+	deadline, hasDeadline := ctx.Deadline()
+
+	stopped := context.AfterFunc(ctx, func() {
+		now := time.Now()
+		err, cause := ctx.Err(), context.Cause(ctx)
+
+		msg := "cancelled after " + now.Sub(start).String() + ": " + err.Error()
+		fields := ContextFields(ctx)
+		fields = append(fields[:len(fields):len(fields)], KV{"error", err.Error()})
+
+		if cause != nil && cause != err {
+			msg += " (" + cause.Error() + ")"
+			fields = append(fields, KV{"cause", cause.Error()})
+		}
+
+		if hasDeadline {
+			fields = append(fields, KV{"remaining", deadline.Sub(now)})
+		}
+
+		n.emit(LevelWarn, msg, withDuration(fields, now.Sub(start)))
+	})
+
+	return func() {
+		stopped()
+	}
+}
+
+// Return `fields` with the "duration" `d`.
+func withDuration(fields []KV, d time.Duration) []KV {
+	return append(fields[:len(fields):len(fields)], KV{"duration", d})
+}
@@ -0,0 +1,82 @@
+package debug
+
+import "bytes"
+import "context"
+import "errors"
+import "strings"
+import "testing"
+import "time"
+
+func TestWatch(t *testing.T) {
+	var b []byte
+	buf := bytes.NewBuffer(b)
+	SetWriter(buf)
+
+	Enable("watch")
+	defer Disable()
+
+	ctx := WithField(context.Background(), "rpc", "get")
+
+	Watch(ctx, "watch", time.Second)()
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output for a fast operation, got %q", buf.String())
+	}
+
+	done := Watch(ctx, "watch", 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	// The warning is written by a timer, under the lock.
+	m.Lock()
+	str := buf.String()
+	m.Unlock()
+	assertContains(t, str, "WRN\033[0m - still running after 10ms rpc=get")
+
+	done()
+	assertContains(t, buf.String(), "completed after ")
+	assertContains(t, buf.String(), "rpc=get duration=")
+}
+
+func TestWatchCtx(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer Swap(buf, "watchctx")()
+
+	ctx := WithField(context.Background(), "rpc", "get")
+
+	ctx1, cancel1 := context.WithCancel(ctx)
+	WatchCtx(ctx1, "watchctx")()
+	cancel1()
+
+	ctx2, cancel2 := context.WithTimeoutCause(ctx, time.Hour, errors.New("slow backend"))
+	defer cancel2()
+	WatchCtx(ctx2, "watchctx")
+	time.Sleep(10 * time.Millisecond)
+	cancel2()
+
+	// The warning is written by another goroutine, under the lock.
+	var str string
+	for i := 0; i < 100 && str == ""; i++ {
+		time.Sleep(time.Millisecond)
+		m.Lock()
+		str = buf.String()
+		m.Unlock()
+	}
+
+	assertContains(t, str, " - cancelled after ")
+	assertContains(t, str, ": context canceled rpc=get error=context canceled remaining=59m")
+	assertContains(t, str, " duration=")
+	assertNotContains(t, str, "cause=")
+
+	ctx3, cancel3 := context.WithDeadlineCause(ctx, time.Now().Add(5*time.Millisecond), errors.New("slow backend"))
+	defer cancel3()
+	WatchCtx(ctx3, "watchctx")
+	<-ctx3.Done()
+
+	for i := 0; i < 100 && !strings.Contains(str, "cause="); i++ {
+		time.Sleep(time.Millisecond)
+		m.Lock()
+		str = buf.String()
+		m.Unlock()
+	}
+
+	assertContains(t, str, ": context deadline exceeded (slow backend) rpc=get error=context deadline exceeded cause=slow backend remaining=-")
+}
@@ -0,0 +1,35 @@
+package debug
+
+import "testing"
+import "time"
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		s     string
+		width int
+	}{
+		{"db:pool", 7},
+		{"データ:接続", 11},
+		{"api:🚀", 6},
+		{"café", 4},
+		{"café", 4},
+		{"👍️", 2},
+	}
+
+	for _, c := range cases {
+		if w := DisplayWidth(c.s); w != c.width {
+			t.Errorf("expected width %d for %q, got %d", c.width, c.s, w)
+		}
+	}
+}
+
+func TestWideAlignment(t *testing.T) {
+	if a := Abbreviate("サービス:データ:接続", 9); a != "…:接続" {
+		t.Fatalf("unexpected abbreviation %q", a)
+	}
+
+	f := &prettyFormat{}
+	f.Format(nil, &Record{Name: "データ"}, "")
+	got := string(f.Format(nil, &Record{Name: "db", Delta: time.Millisecond}, ""))
+	assertContains(t, got, " db     DBG ")
+}